@@ -0,0 +1,103 @@
+package alert
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/typed"
+	"github.com/mdelclaro/gobrax/src/services/apikey"
+	"github.com/mdelclaro/gobrax/src/services/fuel"
+)
+
+func SetupAlertRoutes(router fiber.Router, repo interfaces.IRepository) {
+	h := NewHandler(repo)
+
+	alerts := router.Group("/alerts")
+	alerts.Get("/fuel", h.GetAllFuelAlerts, middleware.Authorize(middleware.Viewer, apikey.FuelRead))
+	alerts.Post("/fuel/:id/acknowledge", h.AcknowledgeFuelAlert, middleware.Authorize(middleware.Dispatcher, apikey.FuelWrite))
+	alerts.Post("/fuel/:id/dismiss", h.DismissFuelAlert, middleware.Authorize(middleware.Dispatcher, apikey.FuelWrite))
+}
+
+type Handler struct {
+	repo       interfaces.IRepository
+	fuelAlerts *typed.Repository[entities.FuelAlert]
+}
+
+func NewHandler(repo interfaces.IRepository) *Handler {
+	return &Handler{
+		repo:       repo,
+		fuelAlerts: typed.New[entities.FuelAlert](repo),
+	}
+}
+
+type resolveRequest struct {
+	Note string `json:"note"`
+}
+
+// GetAllFuelAlerts lists the fuel alerts, the newest first. They can be
+// filtered like any list, e.g. by status=open.
+func (h *Handler) GetAllFuelAlerts(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
+	}
+
+	if len(opts.Sort) == 0 {
+		opts = opts.OrderBy("createdAt", true).OrderBy("id", true)
+	}
+
+	alerts, total, err := h.fuelAlerts.List(ctx, opts, entities.FuelAlertTransaction)
+	if err != nil {
+		return err
+	}
+
+	if len(alerts) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(alerts, opts.BuildMeta(total)))
+}
+
+func (h *Handler) AcknowledgeFuelAlert(c fiber.Ctx) error {
+	return h.resolveFuelAlert(c, entities.FuelAlertAcknowledged)
+}
+
+func (h *Handler) DismissFuelAlert(c fiber.Ctx) error {
+	return h.resolveFuelAlert(c, entities.FuelAlertDismissed)
+}
+
+func (h *Handler) resolveFuelAlert(c fiber.Ctx, to entities.FuelAlertStatus) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	request := resolveRequest{}
+
+	if len(c.Body()) > 0 {
+		if err := helpers.ParseBody(c, &request); err != nil {
+			return err
+		}
+	}
+
+	alert := entities.FuelAlert{GormModel: entities.GormModel{ID: int32(parsedId)}}
+
+	if err := fuel.Resolve(ctx, h.repo, &alert, to, request.Note, middleware.Actor(c)); err != nil {
+		return err
+	}
+
+	helpers.SetETag(c, alert.Version)
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(alert))
+}
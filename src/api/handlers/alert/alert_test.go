@@ -0,0 +1,231 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/memory"
+	"github.com/mdelclaro/gobrax/src/services/fuel"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupApp(repo interfaces.IRepository) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: helpers.ErrorHandler,
+	})
+	api := app.Group("/api", middleware.WithClaims(&middleware.Claims{Role: middleware.Admin, RegisteredClaims: jwt.RegisteredClaims{Subject: "user"}}))
+
+	SetupAlertRoutes(api, repo)
+
+	return app
+}
+
+func kinds(alerts []entities.FuelAlert) []entities.FuelAlertKind {
+	result := []entities.FuelAlertKind{}
+	for _, alert := range alerts {
+		result = append(result, alert.Kind)
+	}
+
+	return result
+}
+
+// The fills are recorded in order against the same truck, each one compared
+// with the ones before it.
+func TestFuelAlertsInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	truck := entities.Truck{LicensePlate: "ABC1234", VehicleSpecs: entities.VehicleSpecs{TankCapacity: decimal.NewFromInt(300)}}
+	assert.NoError(t, repo.Create(ctx, &truck))
+
+	start := time.Date(2024, 8, 1, 8, 0, 0, 0, time.UTC)
+
+	fills := []struct {
+		name string
+
+		after    time.Duration
+		liters   int64
+		odometer int64
+		station  string
+
+		expectedKmPerLiter string
+		expectedAlerts     []entities.FuelAlertKind
+	}{
+		{name: "First Fill", liters: 100, odometer: 1000, station: "Posto Graal", expectedAlerts: []entities.FuelAlertKind{}},
+		{name: "Regular Fill", after: 24 * time.Hour, liters: 100, odometer: 1300, station: "Posto Graal", expectedKmPerLiter: "3", expectedAlerts: []entities.FuelAlertKind{}},
+		{name: "Regular Fill Again", after: 48 * time.Hour, liters: 100, odometer: 1600, station: "Posto Graal", expectedKmPerLiter: "3", expectedAlerts: []entities.FuelAlertKind{}},
+		{name: "Fill Without Baseline Yet", after: 72 * time.Hour, liters: 100, odometer: 1800, station: "Posto Graal", expectedKmPerLiter: "2", expectedAlerts: []entities.FuelAlertKind{}},
+		{
+			name:  "High Consumption",
+			after: 96 * time.Hour, liters: 100, odometer: 1900, station: "Posto Graal",
+			expectedKmPerLiter: "1",
+			expectedAlerts:     []entities.FuelAlertKind{entities.FuelAlertHighConsumption},
+		},
+		{
+			name:  "Over Capacity At Another Station Without Moving",
+			after: 96*time.Hour + 10*time.Minute, liters: 400, odometer: 1900, station: "Posto Shell",
+			expectedKmPerLiter: "0",
+			expectedAlerts:     []entities.FuelAlertKind{entities.FuelAlertOverCapacity, entities.FuelAlertNoDistance, entities.FuelAlertStationHop},
+		},
+		{
+			name:  "Without Odometer",
+			after: 120 * time.Hour, liters: 100, station: "Posto Graal",
+			expectedAlerts: []entities.FuelAlertKind{},
+		},
+	}
+
+	for _, fill := range fills {
+		t.Run("[Success] - "+fill.name, func(t *testing.T) {
+			transaction := entities.FuelTransaction{
+				TruckID:      truck.ID,
+				Liters:       decimal.NewFromInt(fill.liters),
+				Odometer:     decimal.NewFromInt(fill.odometer),
+				Station:      fill.station,
+				TransactedAt: start.Add(fill.after),
+			}
+
+			assert.NoError(t, fuel.Validate(&transaction))
+			assert.NoError(t, fuel.Record(ctx, repo, &transaction))
+			assert.Equal(t, fill.expectedAlerts, kinds(transaction.Alerts))
+
+			if fill.expectedKmPerLiter == "" {
+				assert.Nil(t, transaction.KmPerLiter)
+				return
+			}
+
+			if assert.NotNil(t, transaction.KmPerLiter) {
+				assert.Equal(t, fill.expectedKmPerLiter, transaction.KmPerLiter.String())
+			}
+		})
+	}
+
+	hop := entities.FuelAlert{}
+	assert.NoError(t, repo.FindById(ctx, &hop, 4))
+	assert.Equal(t, entities.FuelAlertStationHop, hop.Kind)
+	assert.Equal(t, int32(5), *hop.RelatedTransactionID)
+	assert.Equal(t, entities.FuelAlertOpen, hop.Status)
+
+	tests := []struct {
+		name string
+
+		method string
+		route  string
+		body   string
+
+		expectedCode   int
+		expectedError  string
+		expectedTotal  int64
+		expectedStatus entities.FuelAlertStatus
+	}{
+		{
+			name:          "[Success] - List Open Alerts",
+			method:        "GET",
+			route:         "/api/alerts/fuel?status=open",
+			expectedCode:  200,
+			expectedTotal: 4,
+		},
+		{
+			name:          "[Success] - List Alerts Of Kind",
+			method:        "GET",
+			route:         "/api/alerts/fuel?kind=station_hop",
+			expectedCode:  200,
+			expectedTotal: 1,
+		},
+		{
+			name:         "[Success] - List No Alerts",
+			method:       "GET",
+			route:        "/api/alerts/fuel?status=dismissed",
+			expectedCode: 204,
+		},
+		{
+			name:           "[Success] - Acknowledge Alert",
+			method:         "POST",
+			route:          "/api/alerts/fuel/4/acknowledge",
+			body:           `{"note":"driver was asked"}`,
+			expectedCode:   200,
+			expectedStatus: entities.FuelAlertAcknowledged,
+		},
+		{
+			name:           "[Success] - Dismiss Acknowledged Alert",
+			method:         "POST",
+			route:          "/api/alerts/fuel/4/dismiss",
+			expectedCode:   200,
+			expectedStatus: entities.FuelAlertDismissed,
+		},
+		{
+			name:          "[Invalid] - Acknowledge Dismissed Alert",
+			method:        "POST",
+			route:         "/api/alerts/fuel/4/acknowledge",
+			expectedCode:  409,
+			expectedError: "invalid fuel alert transition: alert can't go from dismissed to acknowledged",
+		},
+		{
+			name:          "[Invalid] - Dismiss Unknown Alert",
+			method:        "POST",
+			route:         "/api/alerts/fuel/99/dismiss",
+			expectedCode:  404,
+			expectedError: "fuel alert not found",
+		},
+		{
+			name:          "[Invalid] - Dismiss With Invalid Id",
+			method:        "POST",
+			route:         "/api/alerts/fuel/abc/dismiss",
+			expectedCode:  400,
+			expectedError: `invalid id provided: strconv.Atoi: parsing "abc": invalid syntax`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.route, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			body, _ := io.ReadAll(res.Body)
+
+			if tt.expectedError != "" {
+				parsedBody, _ := json.Marshal(helpers.BuildError(errors.New(tt.expectedError)))
+				assert.Equal(t, string(parsedBody), string(body))
+			}
+
+			if tt.expectedTotal != 0 {
+				result := struct {
+					Meta struct {
+						Total int64 `json:"total"`
+					} `json:"meta"`
+				}{}
+				assert.NoError(t, json.Unmarshal(body, &result))
+				assert.Equal(t, tt.expectedTotal, result.Meta.Total, fmt.Sprint(string(body)))
+			}
+
+			if tt.expectedStatus != "" {
+				result := struct {
+					Data entities.FuelAlert `json:"data"`
+				}{}
+				assert.NoError(t, json.Unmarshal(body, &result))
+				assert.Equal(t, tt.expectedStatus, result.Data.Status)
+				assert.Equal(t, "user", result.Data.ResolvedBy)
+				assert.Equal(t, "driver was asked", result.Data.Note)
+				assert.NotNil(t, result.Data.ResolvedAt)
+			}
+		})
+	}
+}
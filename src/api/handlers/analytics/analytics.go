@@ -0,0 +1,68 @@
+package analytics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/services/analytics"
+	"github.com/mdelclaro/gobrax/src/services/apikey"
+)
+
+func SetupAnalyticsRoutes(router fiber.Router, repo interfaces.IRepository) {
+	h := NewHandler(repo)
+
+	analytics := router.Group("/analytics")
+	analytics.Get("/efficiency", h.GetFuelEfficiency, middleware.Authorize(middleware.Viewer, apikey.AnalyticsRead))
+}
+
+type Handler struct {
+	repo interfaces.IRepository
+}
+
+func NewHandler(repo interfaces.IRepository) *Handler {
+	return &Handler{repo: repo}
+}
+
+func (h *Handler) GetFuelEfficiency(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		return err
+	}
+
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		return err
+	}
+
+	if from != nil && to != nil && to.Before(*from) {
+		return apperr.New(apperr.Validation, "to can't be before from")
+	}
+
+	report, err := analytics.FuelEfficiency(ctx, h.repo, from, to)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(report))
+}
+
+func parseTimeQuery(c fiber.Ctx, key string) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, apperr.New(apperr.Validation, "invalid %s provided: %s", key, err.Error())
+	}
+
+	return &parsed, nil
+}
@@ -0,0 +1,220 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	database "github.com/mdelclaro/gobrax/src/db"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/memory"
+	"github.com/mdelclaro/gobrax/src/services/analytics"
+	"github.com/mdelclaro/gobrax/src/shared"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	db *sql.DB
+	id int32 = 1
+
+	from = time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	to   = time.Date(2024, 8, 31, 0, 0, 0, 0, time.UTC)
+)
+
+func setupApp(repo interfaces.IRepository) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: helpers.ErrorHandler,
+	})
+	api := app.Group("/api", middleware.WithClaims(&middleware.Claims{Role: middleware.Admin}))
+
+	SetupAnalyticsRoutes(api, repo)
+
+	return app
+}
+
+func ratio(value string) *decimal.Decimal {
+	parsed := decimal.RequireFromString(value)
+	return &parsed
+}
+
+func TestAnalyticsHandlers(t *testing.T) {
+	tests := []struct {
+		name string
+
+		route  string
+		method string
+		body   any
+
+		expectedCode int
+		expectedBody any
+
+		mock func()
+	}{
+		{
+			name:         "[Success] - Test Get Fuel Efficiency",
+			route:        fmt.Sprintf("/api/analytics/efficiency?from=%s&to=%s", from.Format(time.RFC3339), to.Format(time.RFC3339)),
+			method:       "GET",
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": analytics.EfficiencyReport{
+					From: &from,
+					To:   &to,
+					Fleet: analytics.Efficiency{
+						Trips:          2,
+						Distance:       decimal.NewFromInt(350),
+						FuelLiters:     decimal.NewFromInt(100),
+						KmPerLiter:     ratio("3.5"),
+						LitersPer100Km: ratio("28.5714"),
+					},
+					Trucks: []analytics.TruckEfficiency{{
+						TruckID:      id,
+						LicensePlate: "123",
+						Efficiency: analytics.Efficiency{
+							Trips:          2,
+							Distance:       decimal.NewFromInt(350),
+							FuelLiters:     decimal.NewFromInt(100),
+							KmPerLiter:     ratio("3.5"),
+							LitersPer100Km: ratio("28.5714"),
+						},
+					}},
+					Drivers: []analytics.DriverEfficiency{{
+						DriverID: id,
+						Name:     "driver",
+						Efficiency: analytics.Efficiency{
+							Trips:          1,
+							Distance:       decimal.NewFromInt(250),
+							FuelLiters:     decimal.RequireFromString("87.5"),
+							KmPerLiter:     ratio("2.8571"),
+							LitersPer100Km: ratio("35"),
+						},
+					}},
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				count := sqlmock.NewRows([]string{"count"}).AddRow(2)
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM \"trips\" WHERE \"trips\".\"started_at\" >= \\$1 AND \"trips\".\"started_at\" <= \\$2").
+					WithArgs(from, to).
+					WillReturnRows(count)
+
+				trips := sqlmock.NewRows([]string{
+					"id", "truck_id", "driver_id", "distance", "fuel_liters",
+				}).
+					AddRow(1, id, id, "250", "87.5").
+					AddRow(2, id, nil, "100", "12.5")
+				mock.ExpectQuery("SELECT (.+) FROM \"trips\"").WillReturnRows(trips)
+
+				count = sqlmock.NewRows([]string{"count"}).AddRow(1)
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM \"trucks\" WHERE \"trucks\".\"id\" IN \\(\\$1\\)").WillReturnRows(count)
+
+				trucks := sqlmock.NewRows([]string{"id", "license_plate"}).AddRow(id, "123")
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\"").WillReturnRows(trucks)
+
+				count = sqlmock.NewRows([]string{"count"}).AddRow(1)
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM \"drivers\" WHERE \"drivers\".\"id\" IN \\(\\$1\\)").WillReturnRows(count)
+
+				drivers := sqlmock.NewRows([]string{"id", "name"}).AddRow(id, "driver")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnRows(drivers)
+			},
+		},
+		{
+			name:         "[Invalid] - Test Get Fuel Efficiency With Invalid Range",
+			route:        fmt.Sprintf("/api/analytics/efficiency?from=%s&to=%s", to.Format(time.RFC3339), from.Format(time.RFC3339)),
+			method:       "GET",
+			expectedCode: 400,
+			expectedBody: helpers.BuildError(errors.New("to can't be before from")),
+			mock:         func() {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			defer db.Close()
+
+			app := setupApp(shared.InitRepo(database.DB.Db))
+
+			reqBody, err := json.Marshal(tt.body)
+			assert.NoError(t, err)
+
+			bodyReader := bytes.NewReader(reqBody)
+
+			req, _ := http.NewRequest(
+				tt.method,
+				tt.route,
+				bodyReader,
+			)
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			body, _ := io.ReadAll(res.Body)
+			parsedBody, err := json.Marshal(tt.expectedBody)
+			assert.NoError(t, err)
+
+			assert.Equal(t, string(parsedBody), string(body))
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+		})
+	}
+}
+
+// trips of a deleted truck still add up in the fleet, so the truck is kept in
+// the breakdown
+func TestAnalyticsWithDeletedTruckInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	kept := entities.Truck{LicensePlate: "123"}
+	assert.NoError(t, repo.Create(ctx, &kept))
+
+	deleted := entities.Truck{LicensePlate: "456"}
+	assert.NoError(t, repo.Create(ctx, &deleted))
+
+	for _, truck := range []entities.Truck{kept, deleted} {
+		assert.NoError(t, repo.Create(ctx, &entities.Trip{
+			TruckID:    truck.ID,
+			Distance:   decimal.NewFromInt(100),
+			FuelLiters: decimal.NewFromInt(25),
+			StartedAt:  from.Add(24 * time.Hour),
+			EndedAt:    from.Add(25 * time.Hour),
+		}))
+	}
+
+	assert.NoError(t, repo.Delete(ctx, &entities.Truck{}, deleted.ID))
+
+	req, _ := http.NewRequest("GET", "/api/analytics/efficiency", nil)
+	res, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+
+	result := struct {
+		Data analytics.EfficiencyReport `json:"data"`
+	}{}
+	body, _ := io.ReadAll(res.Body)
+	assert.NoError(t, json.Unmarshal(body, &result))
+
+	assert.Equal(t, 2, result.Data.Fleet.Trips)
+	assert.Len(t, result.Data.Trucks, 2)
+
+	trips := 0
+	for _, truck := range result.Data.Trucks {
+		trips += truck.Trips
+	}
+	assert.Equal(t, result.Data.Fleet.Trips, trips)
+}
@@ -0,0 +1,128 @@
+package apikey
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/services/apikey"
+)
+
+// api keys can only be managed by admin users, never by other api keys
+func SetupApiKeyRoutes(router fiber.Router, repo interfaces.IRepository) {
+	h := NewHandler(repo)
+
+	apiKey := router.Group("/api-keys", middleware.RequireRole(middleware.Admin))
+	apiKey.Get("/:id", h.GetApiKeyByID)
+	apiKey.Get("/", h.GetAllApiKeys)
+	apiKey.Post("/", h.AddApiKey)
+	apiKey.Post("/:id/revoke", h.RevokeApiKey)
+}
+
+type Handler struct {
+	repo interfaces.IRepository
+}
+
+func NewHandler(repo interfaces.IRepository) *Handler {
+	return &Handler{repo: repo}
+}
+
+type createdApiKey struct {
+	entities.ApiKey
+	Key string `json:"key"`
+}
+
+func (h *Handler) GetAllApiKeys(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	keys := []entities.ApiKey{}
+
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
+	}
+
+	total, err := h.repo.FindAll(ctx, &keys, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(keys, opts.BuildMeta(total)))
+}
+
+func (h *Handler) GetApiKeyByID(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	key := entities.ApiKey{}
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	if err := h.repo.FindById(ctx, &key, int32(parsedId)); err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(key))
+}
+
+func (h *Handler) AddApiKey(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	key := entities.ApiKey{}
+
+	if err := helpers.ParseBody(c, &key); err != nil {
+		return err
+	}
+
+	if err := helpers.Validate(key); err != nil {
+		return err
+	}
+
+	key.ID = 0
+
+	plain, err := apikey.Create(ctx, h.repo, &key)
+	if err != nil {
+		return err
+	}
+
+	// the plain key is only ever returned here
+	return c.Status(http.StatusCreated).JSON(helpers.ParseResultToMap(createdApiKey{ApiKey: key, Key: plain}))
+}
+
+func (h *Handler) RevokeApiKey(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	key := entities.ApiKey{}
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	if err := h.repo.FindById(ctx, &key, int32(parsedId)); err != nil {
+		return err
+	}
+
+	if key.RevokedAt != nil {
+		return apperr.New(apperr.Conflict, "api key already revoked")
+	}
+
+	if err := apikey.Revoke(ctx, h.repo, &key); err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(key))
+}
@@ -0,0 +1,227 @@
+package apikey
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	database "github.com/mdelclaro/gobrax/src/db"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/mdelclaro/gobrax/src/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	db  *sql.DB
+	now       = time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	id  int32 = 1
+)
+
+func setupApp(repo interfaces.IRepository) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: helpers.ErrorHandler,
+	})
+	api := app.Group("/api", middleware.WithClaims(&middleware.Claims{Role: middleware.Admin}))
+
+	SetupApiKeyRoutes(api, repo)
+
+	return app
+}
+
+func TestApiKeyHandlers(t *testing.T) {
+	tests := []struct {
+		name string
+
+		route  string
+		method string
+		body   any
+
+		expectedCode int
+		expectedBody any
+
+		mock func()
+	}{
+		{
+			name:         "[Success] - Test Get All Api Keys",
+			route:        "/api/api-keys",
+			method:       "GET",
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": []entities.ApiKey{
+					{
+						GormModel: entities.GormModel{
+							ID: id,
+						},
+						Name:   "ingester",
+						Prefix: "abc123",
+						Scopes: entities.StringList{"trucks:read", "trips:write"},
+					},
+				},
+				"meta": query.Meta{
+					Total: 1,
+					Limit: query.DefaultLimit,
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				count := sqlmock.NewRows([]string{"count"}).AddRow(1)
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM \"api_keys\"").WillReturnRows(count)
+
+				keys := sqlmock.NewRows([]string{"id", "name", "prefix", "secret_hash", "scopes"}).
+					AddRow(id, "ingester", "abc123", "hash", "trucks:read,trips:write")
+
+				mock.ExpectQuery("SELECT (.+) FROM \"api_keys\"").WillReturnRows(keys)
+			},
+		},
+		{
+			name:         "[Success] - Test Get Api Key By ID",
+			route:        fmt.Sprintf("/api/api-keys/%d", id),
+			method:       "GET",
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": entities.ApiKey{
+					GormModel: entities.GormModel{
+						ID: id,
+					},
+					Name:       "ingester",
+					Prefix:     "abc123",
+					Scopes:     entities.StringList{"trucks:read"},
+					LastUsedAt: &now,
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				key := sqlmock.NewRows([]string{"id", "name", "prefix", "secret_hash", "scopes", "last_used_at"}).
+					AddRow(id, "ingester", "abc123", "hash", "trucks:read", now)
+
+				mock.ExpectQuery("SELECT (.+) FROM \"api_keys\" WHERE \"api_keys\".\"id\" = \\$1").WillReturnRows(key)
+			},
+		},
+		{
+			name:         "[Invalid] - Test Add Api Key Without Scopes",
+			route:        "/api/api-keys",
+			method:       "POST",
+			body:         entities.ApiKey{Name: "ingester"},
+			expectedCode: 400,
+			expectedBody: map[string]any{
+				"data": helpers.ValidationErrorResponse{
+					Error: helpers.ValidationError{
+						Code: helpers.CodeValidationFailed,
+						Fields: []helpers.FieldError{
+							{Field: "scopes", Rule: "required", Message: "is required"},
+						},
+					},
+				},
+			},
+			mock: func() {},
+		},
+		{
+			name:   "[Invalid] - Test Add Api Key With Empty Scopes",
+			route:  "/api/api-keys",
+			method: "POST",
+			body: entities.ApiKey{
+				Name:   "ingester",
+				Scopes: entities.StringList{},
+			},
+			expectedCode: 400,
+			expectedBody: map[string]any{
+				"data": helpers.ValidationErrorResponse{
+					Error: helpers.ValidationError{
+						Code: helpers.CodeValidationFailed,
+						Fields: []helpers.FieldError{
+							{Field: "scopes", Rule: "min", Message: "must have at least 1 item"},
+						},
+					},
+				},
+			},
+			mock: func() {},
+		},
+		{
+			name:   "[Invalid] - Test Add Api Key With Unknown Scope",
+			route:  "/api/api-keys",
+			method: "POST",
+			body: entities.ApiKey{
+				Name:   "ingester",
+				Scopes: entities.StringList{"trucks:fly"},
+			},
+			expectedCode: 400,
+			expectedBody: helpers.BuildError(errors.New("unknown scope: trucks:fly")),
+			mock:         func() {},
+		},
+		{
+			name:         "[Not Found] - Test Revoke Missing Api Key",
+			route:        fmt.Sprintf("/api/api-keys/%d/revoke", id),
+			method:       "POST",
+			expectedCode: 404,
+			expectedBody: helpers.BuildError(errors.New("api key not found")),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectQuery("SELECT (.+) FROM \"api_keys\"").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name:         "[Conflict] - Test Revoke Revoked Api Key",
+			route:        fmt.Sprintf("/api/api-keys/%d/revoke", id),
+			method:       "POST",
+			expectedCode: 409,
+			expectedBody: helpers.BuildError(errors.New("api key already revoked")),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				key := sqlmock.NewRows([]string{"id", "name", "prefix", "scopes", "revoked_at"}).
+					AddRow(id, "ingester", "abc123", "trucks:read", now)
+
+				mock.ExpectQuery("SELECT (.+) FROM \"api_keys\"").WillReturnRows(key)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			defer db.Close()
+
+			app := setupApp(shared.InitRepo(database.DB.Db))
+
+			reqBody, err := json.Marshal(tt.body)
+			assert.NoError(t, err)
+
+			bodyReader := bytes.NewReader(reqBody)
+
+			req, _ := http.NewRequest(
+				tt.method,
+				tt.route,
+				bodyReader,
+			)
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			body, _ := io.ReadAll(res.Body)
+			parsedBody, err := json.Marshal(tt.expectedBody)
+			assert.NoError(t, err)
+
+			assert.Equal(t, string(parsedBody), string(body))
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+		})
+	}
+}
@@ -1,124 +1,438 @@
 package driver
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
-	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v3"
 	"github.com/mdelclaro/gobrax/src/api/helpers"
-	database "github.com/mdelclaro/gobrax/src/db"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/apperr"
 	"github.com/mdelclaro/gobrax/src/repository/entities"
-	"github.com/mdelclaro/gobrax/src/shared"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/mdelclaro/gobrax/src/repository/typed"
+	"github.com/mdelclaro/gobrax/src/services/apikey"
+	"github.com/mdelclaro/gobrax/src/services/assignment"
+	"github.com/mdelclaro/gobrax/src/services/driverstatus"
+	"github.com/mdelclaro/gobrax/src/services/license"
 )
 
-func SetupDriverRoutes(router fiber.Router) {
+func SetupDriverRoutes(router fiber.Router, repo interfaces.IRepository) {
+	h := NewHandler(repo)
+
 	driver := router.Group("/driver")
-	driver.Get("/:id", GetDriverByID)
-	driver.Get("/", GetAllDrivers)
-	driver.Post("/", AddDriver)
-	driver.Put("/", UpdateDriver)
-	driver.Delete("/:id", DeleteDriver)
+	driver.Get("/expiring", h.GetExpiringDrivers, middleware.Authorize(middleware.Viewer, apikey.DriversRead))
+	driver.Get("/:id", h.GetDriverByID, middleware.Authorize(middleware.Viewer, apikey.DriversRead))
+	driver.Get("/", h.GetAllDrivers, middleware.Authorize(middleware.Viewer, apikey.DriversRead))
+	driver.Post("/", h.AddDriver, middleware.Authorize(middleware.Dispatcher, apikey.DriversWrite))
+	driver.Put("/", h.UpdateDriver, middleware.Authorize(middleware.Dispatcher, apikey.DriversWrite))
+	driver.Patch("/:id", h.PatchDriver, middleware.Authorize(middleware.Dispatcher, apikey.DriversWrite))
+	driver.Delete("/:id", h.DeleteDriver, middleware.Authorize(middleware.Admin, apikey.DriversDelete))
+	driver.Get("/:id/assignments", h.GetDriverAssignments, middleware.Authorize(middleware.Viewer, apikey.AssignmentsRead))
+	driver.Post("/:id/status", h.ChangeDriverStatus, middleware.Authorize(middleware.Dispatcher, apikey.DriversWrite))
+	driver.Get("/:id/status-changes", h.GetDriverStatusChanges, middleware.Authorize(middleware.Viewer, apikey.DriversRead))
+	driver.Post("/:id/restore", h.RestoreDriver, middleware.Authorize(middleware.Admin, apikey.DriversDelete))
+}
+
+// patchableFields maps the json fields a merge patch can change to their columns.
+var patchableFields = map[string]string{
+	"name":          "name",
+	"licenseNumber": "license_number",
+
+	"licenseCategory":  "license_category",
+	"licenseState":     "license_state",
+	"licenseIssuedAt":  "license_issued_at",
+	"licenseExpiresAt": "license_expires_at",
+}
+
+var errStatusReadOnly = apperr.New(apperr.Validation, "status can't be set directly, use POST /driver/:id/status")
+
+type Handler struct {
+	repo          interfaces.IRepository
+	drivers       *typed.Repository[entities.Driver]
+	assignments   *typed.Repository[entities.TruckAssignment]
+	statusChanges *typed.Repository[entities.DriverStatusChange]
+}
+
+func NewHandler(repo interfaces.IRepository) *Handler {
+	return &Handler{
+		repo:          repo,
+		drivers:       typed.New[entities.Driver](repo),
+		assignments:   typed.New[entities.TruckAssignment](repo),
+		statusChanges: typed.New[entities.DriverStatusChange](repo),
+	}
+}
+
+type statusChangeRequest struct {
+	Status entities.DriverStatus `json:"status" validate:"required,oneof=onboarding active suspended on_leave terminated"`
+	Reason string                `json:"reason" validate:"required"`
 }
 
-func GetAllDrivers(c fiber.Ctx) error {
-	drivers := []entities.Driver{}
+func (h *Handler) GetAllDrivers(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
+	}
 
-	if err := shared.InitRepo(database.DB.Db).FindAll(&drivers); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	drivers, total, err := h.drivers.List(ctx, opts)
+	if err != nil {
+		return err
 	}
 
 	if len(drivers) == 0 {
 		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
 	}
 
-	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(drivers))
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(drivers, opts.BuildMeta(total)))
 }
 
-func GetDriverByID(c fiber.Ctx) error {
-	driver := entities.Driver{}
+// GetExpiringDrivers lists the drivers, terminated ones aside, whose license
+// expires within the next withinDays days (30 by default), soonest first.
+func (h *Handler) GetExpiringDrivers(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	withinDays := 30
+	if rawDays := c.Query("withinDays"); rawDays != "" {
+		parsed, err := strconv.Atoi(rawDays)
+		if err != nil || parsed < 1 {
+			return apperr.New(apperr.Validation, "invalid withinDays provided")
+		}
+
+		withinDays = parsed
+	}
+
+	opts, err := helpers.ParseQueryOptions(c, "withinDays")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	opts = opts.
+		Where("licenseExpiresAt", query.GreaterOrEqual, now).
+		Where("licenseExpiresAt", query.LessOrEqual, now.AddDate(0, 0, withinDays)).
+		Where("status", query.NotEqual, entities.DriverTerminated)
+
+	if len(opts.Sort) == 0 {
+		opts = opts.OrderBy("licenseExpiresAt", false)
+	}
+
+	drivers, total, err := h.drivers.List(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(drivers) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(drivers, opts.BuildMeta(total)))
+}
+
+func (h *Handler) GetDriverByID(c fiber.Ctx) error {
+	ctx := c.UserContext()
 
 	id := c.Params("id")
 	parsedId, err := strconv.Atoi(id)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("invalid id provided: %s", err.Error())))
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	driver, err := h.drivers.Get(ctx, int32(parsedId))
+	if err != nil {
+		return err
+	}
+
+	helpers.SetETag(c, driver.Version)
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(driver))
+}
+
+func (h *Handler) AddDriver(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	driver := entities.Driver{}
+
+	if err := helpers.ParseBody(c, &driver); err != nil {
+		return err
+	}
+
+	if err := helpers.Validate(driver); err != nil {
+		return err
+	}
+
+	if err := license.Validate(&driver); err != nil {
+		return err
 	}
 
-	if err := shared.InitRepo(database.DB.Db).FindById(&driver, int32(parsedId)); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	// new drivers start onboarding
+	if driver.Status != "" {
+		return errStatusReadOnly
+	}
+
+	if err := h.drivers.Create(ctx, &driver); err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(helpers.ParseResultToMap(driver))
+}
+
+func (h *Handler) UpdateDriver(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	driver := entities.Driver{}
+
+	if err := helpers.ParseBody(c, &driver); err != nil {
+		return err
 	}
 
 	if driver.ID == 0 {
-		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+		return apperr.New(apperr.Validation, "id is required")
 	}
 
+	if driver.Status != "" {
+		return errStatusReadOnly
+	}
+
+	if err := license.Validate(&driver); err != nil {
+		return err
+	}
+
+	err := h.repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		// locked, so the version checked is the one being updated
+		current := entities.Driver{}
+		if err := tx.FindByIdForUpdate(ctx, &current, driver.ID); err != nil {
+			return err
+		}
+
+		if err := helpers.CheckIfMatch(c, current.Version); err != nil {
+			return err
+		}
+
+		return tx.Update(ctx, &driver)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	helpers.SetETag(c, driver.Version)
+
 	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(driver))
 }
 
-func AddDriver(c fiber.Ctx) error {
-	driver := entities.Driver{}
+// PatchDriver applies a JSON merge patch to a driver, unlike UpdateDriver it
+// writes false and zero values.
+func (h *Handler) PatchDriver(c fiber.Ctx) error {
+	ctx := c.UserContext()
 
-	if err := json.Unmarshal(c.Body(), &driver); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
 	}
 
-	if err := helpers.Validator.Struct(driver); err != nil {
-		required := ""
+	var driver entities.Driver
 
-		var valErrs validator.ValidationErrors
-		if errors.As(err, &valErrs) {
+	err = h.repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		current := entities.Driver{}
+		if err := tx.FindByIdForUpdate(ctx, &current, int32(parsedId)); err != nil {
+			return err
+		}
 
-			for i, err := range valErrs {
-				field := err.Field()
-				if i != 0 {
-					field = ", " + field
-				}
+		if err := helpers.CheckIfMatch(c, current.Version); err != nil {
+			return err
+		}
 
-				required = required + field
-			}
+		columns, err := helpers.MergePatch(c, &current, patchableFields)
+		if err != nil {
+			return err
 		}
 
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("missing required field(s): %s", required)))
+		driver, err = typed.New[entities.Driver](tx).Patch(ctx, int32(parsedId), columns)
+		if err != nil {
+			return err
+		}
+
+		// the dates have to agree with the ones that weren't patched
+		return license.Validate(&driver)
+	})
+
+	if err != nil {
+		return err
 	}
 
-	if err := shared.InitRepo(database.DB.Db).Create(&driver); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	helpers.SetETag(c, driver.Version)
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(driver))
+}
+
+func (h *Handler) DeleteDriver(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
 	}
 
-	return c.Status(http.StatusCreated).JSON(helpers.ParseResultToMap(driver))
+	force := fiber.Query[bool](c, "force")
+
+	err = h.repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		// locked, so the driver can't be assigned while it is deleted
+		driver := entities.Driver{}
+		if err := tx.FindByIdForUpdate(ctx, &driver, int32(parsedId)); err != nil {
+			return err
+		}
+
+		if err := helpers.CheckIfMatch(c, driver.Version); err != nil {
+			return err
+		}
+
+		truck, err := assignment.TruckOfDriver(ctx, tx, int32(parsedId))
+		if err != nil {
+			return err
+		}
+
+		if truck.ID != 0 && !force {
+			return apperr.New(apperr.Conflict, "driver is assigned to truck %d, use force=true to detach it", truck.ID)
+		}
+
+		if truck.ID != 0 {
+			if err := assignment.Unassign(ctx, tx, &truck, assignment.ReasonDeleted); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(ctx, &entities.Driver{}, int32(parsedId))
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(""))
 }
 
-func UpdateDriver(c fiber.Ctx) error {
+func (h *Handler) RestoreDriver(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
 	driver := entities.Driver{}
 
-	if err := json.Unmarshal(c.Body(), &driver); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(err.Error())
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
 	}
 
-	if driver.ID == 0 {
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("id is required")))
+	if err := h.repo.Restore(ctx, &driver, int32(parsedId)); err != nil {
+		return err
 	}
 
-	if err := shared.InitRepo(database.DB.Db).Update(&driver); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	driver, err = h.drivers.Get(ctx, int32(parsedId))
+	if err != nil {
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(driver))
 }
 
-func DeleteDriver(c fiber.Ctx) error {
+func (h *Handler) GetDriverAssignments(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
 	id := c.Params("id")
 	parsedId, err := strconv.Atoi(id)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("invalid id provided: %s", err.Error())))
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
 	}
 
-	if err := shared.InitRepo(database.DB.Db).Delete(entities.Driver{}, int32(parsedId)); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(""))
+	opts = opts.Where("driverId", query.Equal, int32(parsedId))
+
+	if len(opts.Sort) == 0 {
+		opts = opts.OrderBy("startedAt", true)
+	}
+
+	assignments, total, err := h.assignments.List(ctx, opts, entities.TruckAssignmentTruck)
+	if err != nil {
+		return err
+	}
+
+	if len(assignments) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(assignments, opts.BuildMeta(total)))
+}
+
+// ChangeDriverStatus moves the driver to another status, taking them off
+// their truck when they stop being active.
+func (h *Handler) ChangeDriverStatus(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	request := statusChangeRequest{}
+
+	if err := helpers.ParseBody(c, &request); err != nil {
+		return err
+	}
+
+	if err := helpers.Validate(request); err != nil {
+		return err
+	}
+
+	driver := entities.Driver{GormModel: entities.GormModel{ID: int32(parsedId)}}
+
+	if _, err := driverstatus.Change(ctx, h.repo, &driver, request.Status, request.Reason, middleware.Actor(c)); err != nil {
+		return err
+	}
+
+	helpers.SetETag(c, driver.Version)
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(driver))
+}
+
+func (h *Handler) GetDriverStatusChanges(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
+	}
+
+	opts = opts.Where("driverId", query.Equal, int32(parsedId))
+
+	if len(opts.Sort) == 0 {
+		opts = opts.OrderBy("changedAt", true)
+	}
+
+	changes, total, err := h.statusChanges.List(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(changes, opts.BuildMeta(total)))
 }
@@ -2,48 +2,58 @@ package driver
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofiber/fiber/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
 	database "github.com/mdelclaro/gobrax/src/db"
 	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/memory"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/mdelclaro/gobrax/src/services/assignment"
+	"github.com/mdelclaro/gobrax/src/shared"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
-	app *fiber.App
 	db  *sql.DB
 	now       = time.Time{}
 	id  int32 = 1
 )
 
-func TestMain(m *testing.M) {
-	app = fiber.New()
-	api := app.Group("/api")
+func setupApp(repo interfaces.IRepository) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: helpers.ErrorHandler,
+	})
+	api := app.Group("/api", middleware.WithClaims(&middleware.Claims{Role: middleware.Admin}))
 
-	SetupDriverRoutes(api)
+	SetupDriverRoutes(api, repo)
 
-	exitCode := m.Run()
-	os.Exit(exitCode)
+	return app
 }
 
 func TestDriverHandlers(t *testing.T) {
 	tests := []struct {
 		name string
 
-		route  string
-		method string
-		body   any
+		route   string
+		method  string
+		body    any
+		headers map[string]string
 
 		expectedCode int
 		expectedBody any
@@ -63,23 +73,82 @@ func TestDriverHandlers(t *testing.T) {
 						},
 						Name:          "name",
 						LicenseNumber: "123",
-						IsActive:      true,
+						Status:        entities.DriverActive,
 					},
 				},
+				"meta": query.Meta{
+					Total: 1,
+					Limit: query.DefaultLimit,
+				},
 			},
 			mock: func() {
 				dbConn, _, mock := database.StartDbMock(t)
 				db = dbConn
 
+				count := sqlmock.NewRows([]string{"count"}).AddRow(1)
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM \"drivers\"").WillReturnRows(count)
+
 				drivers := sqlmock.NewRows([]string{
-					"id", "name", "license_number", "is_active",
+					"id", "name", "license_number", "status",
 				}).
-					AddRow(id, "name", "123", true)
+					AddRow(id, "name", "123", "active")
 
 				expectedSQL := "SELECT (.+) FROM \"drivers\""
 				mock.ExpectQuery(expectedSQL).WillReturnRows(drivers)
 			},
 		},
+		{
+			name:         "[Success] - Test Get All Drivers Filtered And Paginated",
+			route:        "/api/driver?status=active&name~=na&sort=-name&page=2&pageSize=1",
+			method:       "GET",
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": []entities.Driver{
+					{
+						GormModel: entities.GormModel{
+							ID: id,
+						},
+						Name:          "name",
+						LicenseNumber: "123",
+						Status:        entities.DriverActive,
+					},
+				},
+				"meta": query.Meta{
+					Total:      3,
+					Limit:      1,
+					Page:       2,
+					NextCursor: query.EncodeCursor(2),
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				count := sqlmock.NewRows([]string{"count"}).AddRow(3)
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM \"drivers\" WHERE CAST\\(\"drivers\".\"name\" AS TEXT\\) ILIKE \\$1 AND \"drivers\".\"status\" = \\$2").
+					WithArgs("%na%", "active").
+					WillReturnRows(count)
+
+				drivers := sqlmock.NewRows([]string{
+					"id", "name", "license_number", "status",
+				}).
+					AddRow(id, "name", "123", "active")
+
+				expectedSQL := "SELECT (.+) FROM \"drivers\" WHERE (.+) ORDER BY \"drivers\".\"name\" DESC,\"drivers\".\"id\" LIMIT \\$3 OFFSET \\$4"
+				mock.ExpectQuery(expectedSQL).WithArgs("%na%", "active", 1, 1).WillReturnRows(drivers)
+			},
+		},
+		{
+			name:         "[Invalid] - Test Get All Drivers With Unknown Filter",
+			route:        "/api/driver?unknown=1",
+			method:       "GET",
+			expectedCode: 400,
+			expectedBody: helpers.BuildError(fmt.Errorf("%w: %s", query.ErrUnknownField, "unknown")),
+			mock: func() {
+				dbConn, _, _ := database.StartDbMock(t)
+				db = dbConn
+			},
+		},
 		{
 			name:         "[Success] - Test Get Driver By Id",
 			route:        fmt.Sprintf("/api/driver/%d", id),
@@ -92,7 +161,7 @@ func TestDriverHandlers(t *testing.T) {
 					},
 					Name:          "name",
 					LicenseNumber: "123",
-					IsActive:      true,
+					Status:        entities.DriverActive,
 				},
 			},
 			mock: func() {
@@ -100,9 +169,9 @@ func TestDriverHandlers(t *testing.T) {
 				db = dbConn
 
 				driver := sqlmock.NewRows([]string{
-					"id", "name", "license_number", "is_active",
+					"id", "name", "license_number", "status",
 				}).
-					AddRow(id, "name", "123", true)
+					AddRow(id, "name", "123", "active")
 
 				expectedSQL := "SELECT (.+) FROM \"drivers\""
 				mock.ExpectQuery(expectedSQL).WillReturnRows(driver)
@@ -116,6 +185,82 @@ func TestDriverHandlers(t *testing.T) {
 			expectedBody: helpers.BuildError(fmt.Errorf("invalid id provided: %s", errors.New("strconv.Atoi: parsing \"INVALID\": invalid syntax"))),
 			mock:         func() {},
 		},
+		{
+			name:         "[Not Found] - Test Get Driver By Id Not Found",
+			route:        fmt.Sprintf("/api/driver/%d", id),
+			method:       "GET",
+			expectedCode: 404,
+			expectedBody: helpers.BuildError(errors.New("driver not found")),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name:         "[Internal] - Test Get Driver By Id Database Failure",
+			route:        fmt.Sprintf("/api/driver/%d", id),
+			method:       "GET",
+			expectedCode: 500,
+			expectedBody: helpers.BuildError(errors.New("Internal Server Error")),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnError(errors.New("connection reset"))
+			},
+		},
+		{
+			name:         "[Invalid] - Test Add Driver With Malformed Body",
+			route:        "/api/driver",
+			method:       "POST",
+			body:         "name",
+			expectedCode: 400,
+			expectedBody: helpers.BuildError(errors.New("invalid body provided: json: cannot unmarshal string into Go value of type entities.Driver")),
+			mock:         func() {},
+		},
+		{
+			name:   "[Conflict] - Test Add Driver With Duplicate License Number",
+			route:  "/api/driver",
+			method: "POST",
+			body: entities.Driver{
+				Name:          "name",
+				LicenseNumber: "123",
+			},
+			expectedCode: 409,
+			expectedBody: helpers.BuildError(errors.New("Key (license_number)=(123) already exists.")),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO \"drivers\" (.+) VALUES (.+)").WillReturnError(&pgconn.PgError{
+					Code:   "23505",
+					Detail: "Key (license_number)=(123) already exists.",
+				})
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:         "[Invalid] - Test Add Driver With Missing Fields",
+			route:        "/api/driver",
+			method:       "POST",
+			body:         entities.Driver{Status: entities.DriverActive},
+			expectedCode: 400,
+			expectedBody: map[string]any{
+				"data": helpers.ValidationErrorResponse{
+					Error: helpers.ValidationError{
+						Code: helpers.CodeValidationFailed,
+						Fields: []helpers.FieldError{
+							{Field: "name", Rule: "required", Message: "is required"},
+							{Field: "licenseNumber", Rule: "required", Message: "is required"},
+						},
+					},
+				},
+			},
+			mock: func() {},
+		},
 		{
 			name:   "[Success] - Test Add Driver",
 			route:  "/api/driver",
@@ -126,17 +271,17 @@ func TestDriverHandlers(t *testing.T) {
 				},
 				Name:          "name",
 				LicenseNumber: "123",
-				IsActive:      true,
 			},
 			expectedCode: 201,
 			expectedBody: map[string]any{
 				"data": entities.Driver{
 					GormModel: entities.GormModel{
-						ID: id,
+						ID:      id,
+						Version: 1,
 					},
 					Name:          "name",
 					LicenseNumber: "123",
-					IsActive:      true,
+					Status:        entities.DriverOnboarding,
 				},
 			},
 			mock: func() {
@@ -144,9 +289,9 @@ func TestDriverHandlers(t *testing.T) {
 				db = dbConn
 
 				row := sqlmock.NewRows([]string{
-					"id", "created_at", "updated_at", "name", "license_number", "is_active",
+					"id", "created_at", "updated_at", "name", "license_number", "status",
 				}).
-					AddRow(id, now, now, "name", "123", true)
+					AddRow(id, now, now, "name", "123", "onboarding")
 
 				expectedSQL := "INSERT INTO \"drivers\" (.+) VALUES (.+)"
 				mock.ExpectBegin()
@@ -165,36 +310,131 @@ func TestDriverHandlers(t *testing.T) {
 				Name: "name_edited",
 			},
 			expectedCode: 200,
+			headers:      map[string]string{"If-Match": `"1"`},
 			expectedBody: map[string]any{
 				"data": entities.Driver{
 					GormModel: entities.GormModel{
-						ID: id,
+						ID:      id,
+						Version: 2,
 					},
 					Name:          "name_edited",
 					LicenseNumber: "123",
-					IsActive:      true,
+					Status:        entities.DriverActive,
 				},
 			},
 			mock: func() {
 				dbConn, _, mock := database.StartDbMock(t)
 				db = dbConn
 
-				expectedSQL := "UPDATE \"drivers\" SET .+"
+				mock.ExpectBegin()
+
+				driver := sqlmock.NewRows([]string{
+					"id", "name", "license_number", "status", "version",
+				}).
+					AddRow(id, "name", "123", "active", 1)
+
+				expectedSQL := "SELECT (.+) FROM \"drivers\" (.+) FOR UPDATE"
+				mock.ExpectQuery(expectedSQL).WillReturnRows(driver)
+
+				expectedSQL = "UPDATE \"drivers\" SET .+"
 				row := sqlmock.NewRows([]string{
-					"id", "created_at", "updated_at", "name", "license_number", "is_active",
+					"id", "created_at", "updated_at", "name", "license_number", "status", "version",
 				}).
-					AddRow(id, now, now, "name_edited", "123", true)
-				mock.ExpectBegin()
+					AddRow(id, now, now, "name_edited", "123", "active", 2)
 				mock.ExpectQuery(expectedSQL).WillReturnRows(row)
 				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "[Precondition Failed] - Test Update Changed Driver",
+			route:  "/api/driver",
+			method: "PUT",
+			body: entities.Driver{
+				GormModel: entities.GormModel{
+					ID: id,
+				},
+				Name: "name_edited",
+			},
+			headers:      map[string]string{"If-Match": `"1"`},
+			expectedCode: 412,
+			expectedBody: helpers.BuildError(errors.New(`record has changed, current version is "2"`)),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
 
 				driver := sqlmock.NewRows([]string{
-					"id", "name", "license_number", "is_active",
+					"id", "name", "license_number", "status", "version",
 				}).
-					AddRow(id, "name", "123", true)
+					AddRow(id, "name", "123", "active", 2)
 
-				expectedSQL = "SELECT (.+) FROM \"drivers\""
-				mock.ExpectQuery(expectedSQL).WillReturnRows(driver)
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\" (.+) FOR UPDATE").WillReturnRows(driver)
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:         "[Success] - Test Patch Driver",
+			route:        fmt.Sprintf("/api/driver/%d", id),
+			method:       "PATCH",
+			body:         map[string]any{"licenseNumber": "456"},
+			headers:      map[string]string{"Content-Type": helpers.MIMEMergePatch},
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": entities.Driver{
+					GormModel: entities.GormModel{
+						ID:      id,
+						Version: 2,
+					},
+					Name:          "name",
+					LicenseNumber: "456",
+					Status:        entities.DriverActive,
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+
+				driver := sqlmock.NewRows([]string{
+					"id", "name", "license_number", "status", "version",
+				}).
+					AddRow(id, "name", "123", "active", 1)
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\" (.+) FOR UPDATE").WillReturnRows(driver)
+
+				mock.ExpectExec("UPDATE \"drivers\" SET \"license_number\"=\\$1,\"updated_at\"=\\$2 WHERE id = \\$3").
+					WithArgs("456", sqlmock.AnyArg(), id).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				patched := sqlmock.NewRows([]string{
+					"id", "updated_at", "name", "license_number", "status", "version",
+				}).
+					AddRow(id, now, "name", "456", "active", 2)
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnRows(patched)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:         "[Validation] - Test Patch Driver Field Not Patchable",
+			route:        fmt.Sprintf("/api/driver/%d", id),
+			method:       "PATCH",
+			body:         map[string]any{"version": 5},
+			headers:      map[string]string{"Content-Type": helpers.MIMEMergePatch},
+			expectedCode: 400,
+			expectedBody: helpers.BuildError(errors.New("version can't be patched")),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+
+				driver := sqlmock.NewRows([]string{
+					"id", "name", "license_number", "status", "version",
+				}).
+					AddRow(id, "name", "123", "active", 1)
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\" (.+) FOR UPDATE").WillReturnRows(driver)
+				mock.ExpectRollback()
 			},
 		},
 		{
@@ -209,13 +449,160 @@ func TestDriverHandlers(t *testing.T) {
 				dbConn, _, mock := database.StartDbMock(t)
 				db = dbConn
 
-				expectedSQL := "DELETE FROM \"drivers\" .+"
-
 				mock.ExpectBegin()
+
+				driver := sqlmock.NewRows([]string{"id", "name", "license_number", "status"}).AddRow(id, "driver", "123", "active")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\" (.+) FOR UPDATE").WillReturnRows(driver)
+
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" WHERE \"trucks\".\"driver_id\" = \\$1").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				expectedSQL := "UPDATE \"drivers\" SET \"deleted_at\"=.+"
+
 				mock.ExpectExec(expectedSQL).WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 			},
 		},
+		{
+			name:         "[Conflict] - Test Delete Driver Assigned To Truck",
+			route:        fmt.Sprintf("/api/driver/%d", id),
+			method:       "DELETE",
+			expectedCode: 409,
+			expectedBody: helpers.BuildError(fmt.Errorf("driver is assigned to truck %d, use force=true to detach it", 2)),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+
+				driver := sqlmock.NewRows([]string{"id", "name", "license_number", "status"}).AddRow(id, "driver", "123", "active")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\" (.+) FOR UPDATE").WillReturnRows(driver)
+
+				truck := sqlmock.NewRows([]string{"id", "license_plate", "driver_id"}).AddRow(2, "123", id)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" WHERE \"trucks\".\"driver_id\" = \\$1").WillReturnRows(truck)
+
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:         "[Success] - Test Force Delete Driver Assigned To Truck",
+			route:        fmt.Sprintf("/api/driver/%d?force=true", id),
+			method:       "DELETE",
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": "",
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+
+				driver := sqlmock.NewRows([]string{"id", "name", "license_number", "status"}).AddRow(id, "driver", "123", "active")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\" (.+) FOR UPDATE").WillReturnRows(driver)
+
+				truck := sqlmock.NewRows([]string{"id", "license_plate", "driver_id"}).AddRow(2, "123", id)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" WHERE \"trucks\".\"driver_id\" = \\$1").WillReturnRows(truck)
+
+				// detach from the truck, closing its assignment
+				truck = sqlmock.NewRows([]string{"id", "license_plate", "driver_id"}).AddRow(2, "123", id)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(truck)
+
+				mock.ExpectQuery("SELECT (.+) FROM \"truck_assignments\" WHERE (.+) IS NULL").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec("UPDATE \"trucks\" SET \"driver_id\"=\\$1").
+					WithArgs(nil, sqlmock.AnyArg(), 2, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec("UPDATE \"drivers\" SET \"deleted_at\"=.+").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:         "[Success] - Test Restore Driver",
+			route:        fmt.Sprintf("/api/driver/%d/restore", id),
+			method:       "POST",
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": entities.Driver{
+					GormModel: entities.GormModel{
+						ID: id,
+					},
+					Name:          "name",
+					LicenseNumber: "123",
+					Status:        entities.DriverActive,
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE \"drivers\" SET \"deleted_at\"=\\$1,\"updated_at\"=\\$2 WHERE id = \\$3 AND deleted_at IS NOT NULL").
+					WithArgs(nil, sqlmock.AnyArg(), id).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+
+				driver := sqlmock.NewRows([]string{
+					"id", "updated_at", "name", "license_number", "status",
+				}).
+					AddRow(id, now, "name", "123", "active")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\" WHERE \"drivers\".\"id\" = \\$1 AND \"drivers\".\"deleted_at\" IS NULL").WillReturnRows(driver)
+			},
+		},
+		{
+			name:         "[Success] - Test Get Driver Assignments",
+			route:        fmt.Sprintf("/api/driver/%d/assignments", id),
+			method:       "GET",
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": []entities.TruckAssignment{{
+					GormModel: entities.GormModel{
+						ID: 1,
+					},
+					TruckID: id,
+					Truck: &entities.Truck{
+						GormModel: entities.GormModel{
+							ID: id,
+						},
+						LicensePlate:     "123",
+						FuelUsed:         decimal.NewFromInt(0),
+						DistanceTraveled: decimal.NewFromInt(0),
+						DriverID:         &id,
+					},
+					DriverID:  id,
+					StartedAt: now,
+					Reason:    "assigned",
+				}},
+				"meta": query.Meta{
+					Total: 1,
+					Limit: query.DefaultLimit,
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				count := sqlmock.NewRows([]string{"count"}).AddRow(1)
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM \"truck_assignments\" WHERE \"truck_assignments\".\"driver_id\" = \\$1").
+					WithArgs(id).
+					WillReturnRows(count)
+
+				assignments := sqlmock.NewRows([]string{
+					"id", "truck_id", "driver_id", "started_at", "ended_at", "reason",
+				}).
+					AddRow(1, id, id, now, nil, "assigned")
+
+				mock.ExpectQuery("SELECT (.+) FROM \"truck_assignments\"").WillReturnRows(assignments)
+
+				truck := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(id, "123", "0", "0", id)
+
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\"").WillReturnRows(truck)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -223,6 +610,8 @@ func TestDriverHandlers(t *testing.T) {
 			tt.mock()
 			defer db.Close()
 
+			app := setupApp(shared.InitRepo(database.DB.Db))
+
 			reqBody, err := json.Marshal(tt.body)
 			assert.NoError(t, err)
 
@@ -234,6 +623,10 @@ func TestDriverHandlers(t *testing.T) {
 				bodyReader,
 			)
 
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
 			res, err := app.Test(req, -1)
 			assert.NoError(t, err)
 
@@ -246,3 +639,182 @@ func TestDriverHandlers(t *testing.T) {
 		})
 	}
 }
+
+func TestDriverStatusInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: helpers.ErrorHandler,
+	})
+	api := app.Group("/api", middleware.WithClaims(&middleware.Claims{
+		Role:             middleware.Admin,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "dispatcher@gobrax"},
+	}))
+	SetupDriverRoutes(api, repo)
+
+	driver := entities.Driver{Name: "driver", LicenseNumber: "123"}
+	assert.NoError(t, repo.Create(ctx, &driver))
+	assert.Equal(t, entities.DriverOnboarding, driver.Status)
+
+	truck := entities.Truck{LicensePlate: "ABC"}
+	assert.NoError(t, repo.Create(ctx, &truck))
+
+	tests := []struct {
+		name string
+
+		body   any
+		before func()
+
+		expectedCode   int
+		expectedStatus entities.DriverStatus
+	}{
+		{
+			name:           "[Success] - Activate",
+			body:           map[string]any{"status": entities.DriverActive, "reason": "documents checked"},
+			expectedCode:   200,
+			expectedStatus: entities.DriverActive,
+		},
+		{
+			name: "[Success] - Suspend Assigned Driver",
+			body: map[string]any{"status": entities.DriverSuspended, "reason": "accident under review"},
+			before: func() {
+				assert.NoError(t, assignment.Assign(ctx, repo, &truck, driver.ID, assignment.ReasonAssigned))
+			},
+			expectedCode:   200,
+			expectedStatus: entities.DriverSuspended,
+		},
+		{
+			name:           "[Error] - Transition Not Allowed",
+			body:           map[string]any{"status": entities.DriverOnLeave, "reason": "vacation"},
+			expectedCode:   409,
+			expectedStatus: entities.DriverSuspended,
+		},
+		{
+			name:           "[Error] - Missing Reason",
+			body:           map[string]any{"status": entities.DriverActive},
+			expectedCode:   400,
+			expectedStatus: entities.DriverSuspended,
+		},
+		{
+			name:           "[Error] - Unknown Status",
+			body:           map[string]any{"status": "retired", "reason": "age"},
+			expectedCode:   400,
+			expectedStatus: entities.DriverSuspended,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.before != nil {
+				tt.before()
+			}
+
+			reqBody, err := json.Marshal(tt.body)
+			assert.NoError(t, err)
+
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/api/driver/%d/status", driver.ID), bytes.NewReader(reqBody))
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			stored := entities.Driver{}
+			assert.NoError(t, repo.FindById(ctx, &stored, driver.ID))
+			assert.Equal(t, tt.expectedStatus, stored.Status)
+		})
+	}
+
+	// leaving active released the truck
+	stored := entities.Truck{}
+	assert.NoError(t, repo.FindById(ctx, &stored, truck.ID))
+	assert.Nil(t, stored.DriverID)
+
+	changes := []entities.DriverStatusChange{}
+	total, err := repo.FindAll(ctx, &changes, query.New().OrderBy("id", false))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, entities.DriverActive, changes[1].From)
+	assert.Equal(t, "accident under review", changes[1].Reason)
+	assert.Equal(t, "dispatcher@gobrax", changes[1].ChangedBy)
+}
+
+func TestExpiringDriversInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	expiresIn := func(days int) *time.Time {
+		at := time.Now().AddDate(0, 0, days)
+		return &at
+	}
+
+	for _, driver := range []entities.Driver{
+		{Name: "soon", LicenseNumber: "1", LicenseExpiresAt: expiresIn(10)},
+		{Name: "later", LicenseNumber: "2", LicenseExpiresAt: expiresIn(60)},
+		{Name: "expired", LicenseNumber: "3", LicenseExpiresAt: expiresIn(-1)},
+		{Name: "unknown", LicenseNumber: "4"},
+		{Name: "sooner", LicenseNumber: "5", LicenseExpiresAt: expiresIn(5)},
+		{Name: "gone", LicenseNumber: "6", LicenseExpiresAt: expiresIn(5), Status: entities.DriverTerminated},
+	} {
+		assert.NoError(t, repo.Create(ctx, &driver))
+	}
+
+	tests := []struct {
+		name string
+
+		route string
+
+		expectedCode  int
+		expectedNames []string
+	}{
+		{
+			name:          "[Success] - Default Window",
+			route:         "/api/driver/expiring",
+			expectedCode:  200,
+			expectedNames: []string{"sooner", "soon"},
+		},
+		{
+			name:          "[Success] - Wider Window",
+			route:         "/api/driver/expiring?withinDays=90",
+			expectedCode:  200,
+			expectedNames: []string{"sooner", "soon", "later"},
+		},
+		{
+			name:         "[Success] - Nothing Expiring",
+			route:        "/api/driver/expiring?withinDays=1",
+			expectedCode: 204,
+		},
+		{
+			name:         "[Error] - Invalid Window",
+			route:        "/api/driver/expiring?withinDays=0",
+			expectedCode: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.route, nil)
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			if len(tt.expectedNames) == 0 {
+				return
+			}
+
+			body := struct {
+				Data []entities.Driver `json:"data"`
+			}{}
+			assert.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+
+			names := []string{}
+			for _, driver := range body.Data {
+				names = append(names, driver.Name)
+			}
+
+			assert.Equal(t, tt.expectedNames, names)
+		})
+	}
+}
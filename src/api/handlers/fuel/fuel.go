@@ -0,0 +1,194 @@
+package fuel
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/typed"
+	"github.com/mdelclaro/gobrax/src/services/apikey"
+	"github.com/mdelclaro/gobrax/src/services/fuel"
+	"github.com/shopspring/decimal"
+)
+
+func SetupFuelRoutes(router fiber.Router, repo interfaces.IRepository) {
+	h := NewHandler(repo)
+
+	fuel := router.Group("/fuel")
+	fuel.Post("/import", h.ImportFuelTransactions, middleware.Authorize(middleware.Dispatcher, apikey.FuelWrite))
+	fuel.Get("/transactions", h.GetAllFuelTransactions, middleware.Authorize(middleware.Viewer, apikey.FuelRead))
+	fuel.Post("/transactions", h.AddFuelTransaction, middleware.Authorize(middleware.Dispatcher, apikey.FuelWrite))
+}
+
+type fuelTransactionRequest struct {
+	TruckID      int32            `json:"truckId" validate:"required"`
+	DriverID     *int32           `json:"driverId"`
+	Liters       *decimal.Decimal `json:"liters" validate:"required"`
+	UnitPrice    decimal.Decimal  `json:"unitPrice"`
+	Total        decimal.Decimal  `json:"total"`
+	Station      string           `json:"station"`
+	CardNumber   string           `json:"cardNumber"`
+	TransactedAt *time.Time       `json:"transactedAt"`
+	Odometer     decimal.Decimal  `json:"odometer"`
+}
+
+type Handler struct {
+	repo         interfaces.IRepository
+	transactions *typed.Repository[entities.FuelTransaction]
+}
+
+func NewHandler(repo interfaces.IRepository) *Handler {
+	return &Handler{
+		repo:         repo,
+		transactions: typed.New[entities.FuelTransaction](repo),
+	}
+}
+
+func (h *Handler) GetAllFuelTransactions(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
+	}
+
+	if len(opts.Sort) == 0 {
+		opts = opts.OrderBy("transactedAt", true)
+	}
+
+	transactions, total, err := h.transactions.List(ctx, opts, entities.FuelTransactionDriver)
+	if err != nil {
+		return err
+	}
+
+	if len(transactions) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(transactions, opts.BuildMeta(total)))
+}
+
+// AddFuelTransaction records a fill by hand, responding with the alerts it
+// raised.
+func (h *Handler) AddFuelTransaction(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	request := fuelTransactionRequest{}
+
+	if err := helpers.ParseBody(c, &request); err != nil {
+		return err
+	}
+
+	if err := helpers.Validate(request); err != nil {
+		return err
+	}
+
+	transaction := entities.FuelTransaction{
+		TruckID:      request.TruckID,
+		DriverID:     request.DriverID,
+		Liters:       *request.Liters,
+		UnitPrice:    request.UnitPrice,
+		Total:        request.Total,
+		Station:      request.Station,
+		CardNumber:   request.CardNumber,
+		TransactedAt: time.Now(),
+		Odometer:     request.Odometer,
+		RecordedBy:   middleware.Actor(c),
+	}
+
+	if request.TransactedAt != nil {
+		transaction.TransactedAt = *request.TransactedAt
+	}
+
+	if err := fuel.Validate(&transaction); err != nil {
+		return err
+	}
+
+	if err := fuel.Record(ctx, h.repo, &transaction); err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(helpers.ParseResultToMap(transaction))
+}
+
+// ImportFuelTransactions imports the fuel card statement uploaded as the
+// multipart field file. The other form fields describe its format: mapping
+// (a json object of field to column header), delimiter, timeLayout (a go
+// time layout), timezone and decimalComma.
+func (h *Handler) ImportFuelTransactions(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return apperr.New(apperr.Validation, "file is required")
+	}
+
+	opts, err := parseImportOptions(c)
+	if err != nil {
+		return err
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return apperr.Wrap(apperr.Internal, err)
+	}
+	defer file.Close()
+
+	report, err := fuel.Import(ctx, h.repo, file, opts, middleware.Actor(c))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(report))
+}
+
+func parseImportOptions(c fiber.Ctx) (fuel.ImportOptions, error) {
+	opts := fuel.ImportOptions{TimeLayout: c.FormValue("timeLayout")}
+
+	if raw := c.FormValue("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts.Mapping); err != nil {
+			return opts, apperr.New(apperr.Validation, "invalid mapping provided: %s", err.Error())
+		}
+	}
+
+	if raw := c.FormValue("delimiter"); raw != "" {
+		if raw == `\t` {
+			raw = "\t"
+		}
+
+		delimiter, size := utf8.DecodeRuneInString(raw)
+		if size != len(raw) || delimiter == '"' || delimiter == '\n' || delimiter == '\r' {
+			return opts, apperr.New(apperr.Validation, "invalid delimiter provided")
+		}
+
+		opts.Delimiter = delimiter
+	}
+
+	if raw := c.FormValue("timezone"); raw != "" {
+		location, err := time.LoadLocation(raw)
+		if err != nil {
+			return opts, apperr.New(apperr.Validation, "invalid timezone provided: %s", err.Error())
+		}
+
+		opts.Location = location
+	}
+
+	if raw := c.FormValue("decimalComma"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, apperr.New(apperr.Validation, "invalid decimalComma provided")
+		}
+
+		opts.DecimalComma = parsed
+	}
+
+	return opts, nil
+}
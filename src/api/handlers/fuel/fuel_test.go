@@ -0,0 +1,400 @@
+package fuel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/memory"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/mdelclaro/gobrax/src/services/fuel"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// a statement as exported by the fuel card provider
+const statement = "Placa;Data;Litros;Preço;Posto\n" +
+	"ABC1234;01/08/2024 08:30;350,5;5,89;Posto Graal\n" +
+	"ZZZ9999;01/08/2024 09:00;100;5,89;Posto Graal\n" +
+	"ABC1234;02/08/2024 10:00;muito;5,89;Posto Graal\n" +
+	"ABC1234;01/08/2024 08:30;350,50;5,89;Posto Graal\n"
+
+var mapping = `{"licensePlate":"Placa","transactedAt":"Data","liters":"Litros","unitPrice":"Preço","station":"Posto"}`
+
+func setupApp(repo interfaces.IRepository) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: helpers.ErrorHandler,
+	})
+	api := app.Group("/api", middleware.WithClaims(&middleware.Claims{Role: middleware.Admin, RegisteredClaims: jwt.RegisteredClaims{Subject: "user"}}))
+
+	SetupFuelRoutes(api, repo)
+
+	return app
+}
+
+func importRequest(t *testing.T, content string, fields map[string]string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for key, value := range fields {
+		assert.NoError(t, writer.WriteField(key, value))
+	}
+
+	if content != "" {
+		file, err := writer.CreateFormFile("file", "statement.csv")
+		assert.NoError(t, err)
+
+		_, err = file.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, writer.Close())
+
+	req, _ := http.NewRequest("POST", "/api/fuel/import", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return req
+}
+
+// The imports run in order against the same repository, so the second one
+// finds the lines of the first.
+func TestFuelImportInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	driver := entities.Driver{Name: "driver", LicenseNumber: "123", Status: entities.DriverActive}
+	assert.NoError(t, repo.Create(ctx, &driver))
+
+	truck := entities.Truck{LicensePlate: "ABC1234", DriverID: &driver.ID}
+	assert.NoError(t, repo.Create(ctx, &truck))
+
+	assert.NoError(t, repo.Create(ctx, &entities.TruckAssignment{
+		TruckID:   truck.ID,
+		DriverID:  driver.ID,
+		StartedAt: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+	}))
+
+	brazilian := map[string]string{
+		"mapping":      mapping,
+		"delimiter":    ";",
+		"decimalComma": "true",
+		"timeLayout":   "02/01/2006 15:04",
+	}
+
+	tests := []struct {
+		name string
+
+		content string
+		fields  map[string]string
+
+		expectedCode   int
+		expectedError  string
+		expectedReport *fuel.ImportReport
+	}{
+		{
+			name:    "[Success] - Import Statement",
+			content: statement,
+			fields:  brazilian,
+
+			expectedCode: 200,
+			expectedReport: &fuel.ImportReport{
+				Imported:   1,
+				Duplicates: 1,
+				Rejected:   2,
+				Rows: []fuel.RowResult{
+					{Line: 2, Status: fuel.RowImported, TransactionID: 1},
+					{Line: 3, Status: fuel.RowRejected, Error: "no truck with license plate ZZZ9999"},
+					{Line: 4, Status: fuel.RowRejected, Error: `invalid liters "muito"`},
+					{Line: 5, Status: fuel.RowDuplicate},
+				},
+			},
+		},
+		{
+			name:    "[Success] - Import Statement Again",
+			content: statement,
+			fields:  brazilian,
+
+			expectedCode: 200,
+			expectedReport: &fuel.ImportReport{
+				Duplicates: 2,
+				Rejected:   2,
+				Rows: []fuel.RowResult{
+					{Line: 2, Status: fuel.RowDuplicate},
+					{Line: 3, Status: fuel.RowRejected, Error: "no truck with license plate ZZZ9999"},
+					{Line: 4, Status: fuel.RowRejected, Error: `invalid liters "muito"`},
+					{Line: 5, Status: fuel.RowDuplicate},
+				},
+			},
+		},
+		{
+			name:    "[Success] - Import With Mismatching Total",
+			content: "licensePlate,transactedAt,liters,unitPrice,total\nABC1234,2024-08-03T10:00:00Z,100,5,600\n",
+
+			expectedCode: 200,
+			expectedReport: &fuel.ImportReport{
+				Rejected: 1,
+				Rows: []fuel.RowResult{
+					{Line: 2, Status: fuel.RowRejected, Error: "total 600 doesn't match liters times unitPrice 500"},
+				},
+			},
+		},
+		{
+			name:          "[Invalid] - Import Without Mapped Column",
+			content:       statement,
+			fields:        map[string]string{"delimiter": ";"},
+			expectedCode:  400,
+			expectedError: "invalid column mapping: column licensePlate for licensePlate not found",
+		},
+		{
+			name:          "[Invalid] - Import Unknown Field",
+			content:       statement,
+			fields:        map[string]string{"mapping": `{"plate":"Placa"}`},
+			expectedCode:  400,
+			expectedError: "invalid column mapping: unknown field plate",
+		},
+		{
+			name:          "[Invalid] - Import Without File",
+			fields:        brazilian,
+			expectedCode:  400,
+			expectedError: "file is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := app.Test(importRequest(t, tt.content, tt.fields), -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			body, _ := io.ReadAll(res.Body)
+
+			if tt.expectedError != "" {
+				parsedBody, _ := json.Marshal(helpers.BuildError(errors.New(tt.expectedError)))
+				assert.Equal(t, string(parsedBody), string(body))
+			}
+
+			if tt.expectedReport != nil {
+				parsedBody, _ := json.Marshal(helpers.ParseResultToMap(tt.expectedReport))
+				assert.Equal(t, string(parsedBody), string(body))
+			}
+		})
+	}
+
+	stored := entities.FuelTransaction{}
+	assert.NoError(t, repo.FindById(ctx, &stored, 1))
+	assert.Equal(t, "350.5", stored.Liters.String())
+	assert.Equal(t, "2064.45", stored.Total.String())
+	assert.Equal(t, time.Date(2024, 8, 1, 8, 30, 0, 0, time.UTC), stored.TransactedAt.UTC())
+	assert.Equal(t, &driver.ID, stored.DriverID)
+	assert.Equal(t, "user", stored.RecordedBy)
+}
+
+func TestAddFuelTransactionInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	truck := entities.Truck{LicensePlate: "ABC1234", VehicleSpecs: entities.VehicleSpecs{TankCapacity: decimal.NewFromInt(300)}}
+	assert.NoError(t, repo.Create(ctx, &truck))
+
+	tests := []struct {
+		name string
+
+		body string
+
+		expectedCode   int
+		expectedError  string
+		expectedAlerts []entities.FuelAlertKind
+	}{
+		{
+			name:           "[Success] - Add Fuel Transaction",
+			body:           `{"truckId":1,"liters":"100","unitPrice":"5.89","odometer":"1000","transactedAt":"2024-08-01T08:00:00Z"}`,
+			expectedCode:   201,
+			expectedAlerts: []entities.FuelAlertKind{},
+		},
+		{
+			name:           "[Success] - Add Fuel Transaction Over Capacity",
+			body:           `{"truckId":1,"liters":"400","odometer":"1500","transactedAt":"2024-08-02T08:00:00Z"}`,
+			expectedCode:   201,
+			expectedAlerts: []entities.FuelAlertKind{entities.FuelAlertOverCapacity},
+		},
+		{
+			name:          "[Invalid] - Add Fuel Transaction With Negative Liters",
+			body:          `{"truckId":1,"liters":"-10"}`,
+			expectedCode:  400,
+			expectedError: "liters must be positive",
+		},
+		{
+			name:          "[Invalid] - Add Fuel Transaction Of Unknown Truck",
+			body:          `{"truckId":99,"liters":"10"}`,
+			expectedCode:  404,
+			expectedError: "truck not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/fuel/transactions", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			body, _ := io.ReadAll(res.Body)
+
+			if tt.expectedError != "" {
+				parsedBody, _ := json.Marshal(helpers.BuildError(errors.New(tt.expectedError)))
+				assert.Equal(t, string(parsedBody), string(body))
+				return
+			}
+
+			result := struct {
+				Data entities.FuelTransaction `json:"data"`
+			}{}
+			assert.NoError(t, json.Unmarshal(body, &result))
+			assert.Equal(t, "user", result.Data.RecordedBy)
+
+			kinds := []entities.FuelAlertKind{}
+			for _, alert := range result.Data.Alerts {
+				kinds = append(kinds, alert.Kind)
+			}
+			assert.Equal(t, tt.expectedAlerts, kinds)
+		})
+	}
+}
+
+// conflictingRepo fails to store fuel transactions with a conflict. When
+// racing, the transaction is stored once the failed one is rolled back, as
+// if another import stored the line first.
+type conflictingRepo struct {
+	interfaces.IRepository
+	racing bool
+}
+
+func (r conflictingRepo) WithTransaction(ctx context.Context, fn func(tx interfaces.IRepository) error) error {
+	var pending *entities.FuelTransaction
+
+	err := r.IRepository.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		return fn(failingTx{IRepository: tx, pending: &pending})
+	})
+
+	if r.racing && pending != nil {
+		stored := *pending
+		stored.ID = 0
+		if err := r.IRepository.Create(ctx, &stored); err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+type failingTx struct {
+	interfaces.IRepository
+	pending **entities.FuelTransaction
+}
+
+func (tx failingTx) Create(ctx context.Context, target any) error {
+	transaction, ok := target.(*entities.FuelTransaction)
+	if !ok {
+		return tx.IRepository.Create(ctx, target)
+	}
+
+	*tx.pending = transaction
+
+	return apperr.New(apperr.Conflict, "deadlock detected")
+}
+
+func TestFuelImportConflictInMemory(t *testing.T) {
+	ctx := context.Background()
+
+	content := "licensePlate,transactedAt,liters\nABC1234,2024-08-01T08:30:00Z,100\n"
+
+	tests := []struct {
+		name string
+
+		racing bool
+
+		expectedReport fuel.ImportReport
+	}{
+		{
+			name:   "[Success] - Import Line Stored Meanwhile",
+			racing: true,
+			expectedReport: fuel.ImportReport{
+				Duplicates: 1,
+				Rows:       []fuel.RowResult{{Line: 2, Status: fuel.RowDuplicate}},
+			},
+		},
+		{
+			name: "[Success] - Import Line Failing With Conflict",
+			expectedReport: fuel.ImportReport{
+				Rejected: 1,
+				Rows:     []fuel.RowResult{{Line: 2, Status: fuel.RowRejected, Error: "deadlock detected"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewRepository()
+			assert.NoError(t, repo.Create(ctx, &entities.Truck{LicensePlate: "ABC1234"}))
+
+			app := setupApp(conflictingRepo{IRepository: repo, racing: tt.racing})
+
+			res, err := app.Test(importRequest(t, content, nil), -1)
+			assert.NoError(t, err)
+			assert.Equal(t, 200, res.StatusCode)
+
+			body, _ := io.ReadAll(res.Body)
+			parsedBody, _ := json.Marshal(helpers.ParseResultToMap(tt.expectedReport))
+			assert.Equal(t, string(parsedBody), string(body))
+		})
+	}
+}
+
+func TestFuelImportTooManyLinesInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	assert.NoError(t, repo.Create(ctx, &entities.Truck{LicensePlate: "ABC1234"}))
+
+	content := strings.Builder{}
+	content.WriteString("licensePlate,transactedAt,liters\n")
+
+	start := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= fuel.MaxImportRows; i++ {
+		fmt.Fprintf(&content, "ABC1234,%s,100\n", start.Add(time.Duration(i)*time.Minute).Format(time.RFC3339))
+	}
+
+	res, err := app.Test(importRequest(t, content.String(), nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+
+	body, _ := io.ReadAll(res.Body)
+	parsedBody, _ := json.Marshal(helpers.BuildError(fmt.Errorf("%w: more than %d lines", fuel.ErrInvalidFile, fuel.MaxImportRows)))
+	assert.Equal(t, string(parsedBody), string(body))
+
+	// refused before any line was stored
+	transactions := []entities.FuelTransaction{}
+	total, err := repo.FindAll(ctx, &transactions, query.New())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
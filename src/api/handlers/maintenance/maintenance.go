@@ -0,0 +1,181 @@
+package maintenance
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/typed"
+	"github.com/mdelclaro/gobrax/src/services/apikey"
+	"github.com/mdelclaro/gobrax/src/services/maintenance"
+	"github.com/shopspring/decimal"
+)
+
+func SetupMaintenanceRoutes(router fiber.Router, repo interfaces.IRepository) {
+	h := NewHandler(repo)
+
+	maintenance := router.Group("/maintenance")
+	maintenance.Get("/due", h.GetDue, middleware.Authorize(middleware.Viewer, apikey.MaintenanceRead))
+	maintenance.Get("/plans", h.GetAllPlans, middleware.Authorize(middleware.Viewer, apikey.MaintenanceRead))
+	maintenance.Post("/plans", h.AddPlan, middleware.Authorize(middleware.Dispatcher, apikey.MaintenanceWrite))
+	maintenance.Get("/events", h.GetAllEvents, middleware.Authorize(middleware.Viewer, apikey.MaintenanceRead))
+	maintenance.Post("/events", h.AddEvent, middleware.Authorize(middleware.Dispatcher, apikey.MaintenanceWrite))
+}
+
+// defaultThreshold lists what is due within the next week of work.
+var defaultThreshold = maintenance.Threshold{
+	Distance:    decimal.NewFromInt(1000),
+	EngineHours: decimal.NewFromInt(50),
+	Days:        7,
+}
+
+type Handler struct {
+	repo   interfaces.IRepository
+	plans  *typed.Repository[entities.MaintenancePlan]
+	events *typed.Repository[entities.MaintenanceEvent]
+}
+
+func NewHandler(repo interfaces.IRepository) *Handler {
+	return &Handler{
+		repo:   repo,
+		plans:  typed.New[entities.MaintenancePlan](repo),
+		events: typed.New[entities.MaintenanceEvent](repo),
+	}
+}
+
+func (h *Handler) GetAllPlans(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
+	}
+
+	plans, total, err := h.plans.List(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(plans) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(plans, opts.BuildMeta(total)))
+}
+
+func (h *Handler) AddPlan(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	plan := entities.MaintenancePlan{}
+
+	if err := helpers.ParseBody(c, &plan); err != nil {
+		return err
+	}
+
+	if err := helpers.Validate(plan); err != nil {
+		return err
+	}
+
+	if err := maintenance.CreatePlan(ctx, h.repo, &plan); err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(helpers.ParseResultToMap(plan))
+}
+
+func (h *Handler) GetAllEvents(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
+	}
+
+	if len(opts.Sort) == 0 {
+		opts = opts.OrderBy("performedAt", true)
+	}
+
+	events, total, err := h.events.List(ctx, opts, entities.MaintenanceEventPlan)
+	if err != nil {
+		return err
+	}
+
+	if len(events) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(events, opts.BuildMeta(total)))
+}
+
+// AddEvent logs a service done on a truck.
+func (h *Handler) AddEvent(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	event := entities.MaintenanceEvent{}
+
+	if err := helpers.ParseBody(c, &event); err != nil {
+		return err
+	}
+
+	if err := helpers.Validate(event); err != nil {
+		return err
+	}
+
+	if err := maintenance.Log(ctx, h.repo, &event); err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(helpers.ParseResultToMap(event))
+}
+
+// GetDue lists the plans overdue or falling due within withinDistance km,
+// withinEngineHours or withinDays, the most overdue first.
+func (h *Handler) GetDue(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	threshold := defaultThreshold
+
+	if raw := c.Query("withinDistance"); raw != "" {
+		parsed, err := decimal.NewFromString(raw)
+		if err != nil || parsed.IsNegative() {
+			return apperr.New(apperr.Validation, "invalid withinDistance provided")
+		}
+
+		threshold.Distance = parsed
+	}
+
+	if raw := c.Query("withinEngineHours"); raw != "" {
+		parsed, err := decimal.NewFromString(raw)
+		if err != nil || parsed.IsNegative() {
+			return apperr.New(apperr.Validation, "invalid withinEngineHours provided")
+		}
+
+		threshold.EngineHours = parsed
+	}
+
+	if raw := c.Query("withinDays"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return apperr.New(apperr.Validation, "invalid withinDays provided")
+		}
+
+		threshold.Days = parsed
+	}
+
+	due, err := maintenance.DueWithin(ctx, h.repo, threshold, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if len(due) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(due))
+}
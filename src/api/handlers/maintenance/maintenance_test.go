@@ -0,0 +1,218 @@
+package maintenance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/memory"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupApp(repo interfaces.IRepository) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: helpers.ErrorHandler,
+	})
+	api := app.Group("/api", middleware.WithClaims(&middleware.Claims{Role: middleware.Admin}))
+
+	SetupMaintenanceRoutes(api, repo)
+
+	return app
+}
+
+type dueResponse struct {
+	Data []struct {
+		TruckID           int32           `json:"truckId"`
+		PlanID            int32           `json:"planId"`
+		RemainingDistance decimal.Decimal `json:"remainingDistance"`
+		Progress          decimal.Decimal `json:"progress"`
+		Overdue           bool            `json:"overdue"`
+	} `json:"data"`
+}
+
+// The steps run in order against the same repository, each one builds on
+// the state left by the previous ones.
+func TestMaintenanceInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	truck := entities.Truck{LicensePlate: "ABC", DistanceTraveled: decimal.NewFromInt(800)}
+	assert.NoError(t, repo.Create(ctx, &truck))
+
+	other := entities.Truck{LicensePlate: "DEF"}
+	assert.NoError(t, repo.Create(ctx, &other))
+
+	// performed after the plan is created, set once it is
+	service := map[string]any{"truckId": truck.ID, "planId": 1, "engineHours": "120"}
+
+	tests := []struct {
+		name string
+
+		route  string
+		method string
+		body   any
+
+		// runs before the request
+		setup func()
+
+		expectedCode  int
+		expectedError string
+		check         func(t *testing.T, body []byte)
+	}{
+		{
+			name:          "[Error] - Add Plan Without Interval",
+			route:         "/api/maintenance/plans",
+			method:        "POST",
+			body:          map[string]any{"truckId": truck.ID, "name": "oil change"},
+			expectedCode:  400,
+			expectedError: "invalid maintenance plan: intervalDistance, intervalEngineHours or intervalDays is required",
+		},
+		{
+			name:         "[Success] - Add Plan",
+			route:        "/api/maintenance/plans",
+			method:       "POST",
+			body:         map[string]any{"truckId": truck.ID, "name": "oil change", "intervalDistance": "1000"},
+			expectedCode: 201,
+			check: func(t *testing.T, body []byte) {
+				var res struct {
+					Data entities.MaintenancePlan `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(body, &res))
+				assert.Equal(t, "800", res.Data.LastServiceDistance.String())
+			},
+		},
+		{
+			name:         "[Success] - Nothing Due Yet",
+			route:        "/api/maintenance/due?withinDistance=100",
+			method:       "GET",
+			expectedCode: 204,
+		},
+		{
+			name:   "[Success] - Due Within Threshold",
+			route:  "/api/maintenance/due?withinDistance=100",
+			method: "GET",
+			setup: func() {
+				assert.NoError(t, repo.Increment(ctx, &entities.Truck{}, truck.ID, "distance_traveled", decimal.NewFromInt(950)))
+			},
+			expectedCode: 200,
+			check: func(t *testing.T, body []byte) {
+				var res dueResponse
+				assert.NoError(t, json.Unmarshal(body, &res))
+				assert.Len(t, res.Data, 1)
+				assert.Equal(t, truck.ID, res.Data[0].TruckID)
+				assert.Equal(t, "50", res.Data[0].RemainingDistance.String())
+				assert.False(t, res.Data[0].Overdue)
+			},
+		},
+		{
+			name:   "[Success] - Overdue",
+			route:  "/api/maintenance/due?withinDistance=0",
+			method: "GET",
+			setup: func() {
+				assert.NoError(t, repo.Increment(ctx, &entities.Truck{}, truck.ID, "distance_traveled", decimal.NewFromInt(100)))
+			},
+			expectedCode: 200,
+			check: func(t *testing.T, body []byte) {
+				var res dueResponse
+				assert.NoError(t, json.Unmarshal(body, &res))
+				assert.Len(t, res.Data, 1)
+				assert.Equal(t, "1.05", res.Data[0].Progress.String())
+				assert.True(t, res.Data[0].Overdue)
+			},
+		},
+		{
+			name:          "[Error] - Log Service For Plan Of Another Truck",
+			route:         "/api/maintenance/events",
+			method:        "POST",
+			body:          map[string]any{"truckId": other.ID, "planId": 1, "performedAt": "2024-08-01T08:00:00Z"},
+			expectedCode:  400,
+			expectedError: "maintenance plan belongs to another truck: plan 1 is for truck 1",
+		},
+		{
+			name:          "[Invalid] - Log Service In The Future",
+			route:         "/api/maintenance/events",
+			method:        "POST",
+			body:          map[string]any{"truckId": truck.ID, "planId": 1, "performedAt": time.Now().AddDate(0, 0, 1)},
+			expectedCode:  400,
+			expectedError: "invalid maintenance event: performedAt can't be in the future",
+		},
+		{
+			name:         "[Success] - Log Service Resets Plan",
+			route:        "/api/maintenance/events",
+			method:       "POST",
+			body:         service,
+			setup:        func() { service["performedAt"] = time.Now() },
+			expectedCode: 201,
+			check: func(t *testing.T, body []byte) {
+				var res struct {
+					Data entities.MaintenanceEvent `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(body, &res))
+				assert.Equal(t, "1850", res.Data.DistanceTraveled.String())
+
+				stored := entities.Truck{}
+				assert.NoError(t, repo.FindById(ctx, &stored, truck.ID))
+				assert.Equal(t, "120", stored.EngineHours.String())
+			},
+		},
+		{
+			name:         "[Success] - Nothing Due After Service",
+			route:        "/api/maintenance/due?withinDistance=100",
+			method:       "GET",
+			expectedCode: 204,
+		},
+		{
+			name:          "[Invalid] - Invalid Threshold",
+			route:         "/api/maintenance/due?withinDays=-1",
+			method:        "GET",
+			expectedCode:  400,
+			expectedError: "invalid withinDays provided",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+
+			var bodyReader io.Reader
+			if tt.body != nil {
+				reqBody, err := json.Marshal(tt.body)
+				assert.NoError(t, err)
+
+				bodyReader = bytes.NewReader(reqBody)
+			}
+
+			req, _ := http.NewRequest(tt.method, tt.route, bodyReader)
+			req.Header.Set("Content-Type", "application/json")
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			body, _ := io.ReadAll(res.Body)
+
+			if tt.expectedError != "" {
+				parsedBody, _ := json.Marshal(helpers.BuildError(errors.New(tt.expectedError)))
+				assert.Equal(t, string(parsedBody), string(body))
+			}
+
+			if tt.check != nil {
+				tt.check(t, body)
+			}
+		})
+	}
+}
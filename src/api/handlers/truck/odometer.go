@@ -0,0 +1,97 @@
+package truck
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/mdelclaro/gobrax/src/services/odometer"
+	"github.com/shopspring/decimal"
+)
+
+// trip_close readings are only recorded by trips
+type odometerReadingRequest struct {
+	Value            *decimal.Decimal        `json:"value" validate:"required"`
+	Source           entities.OdometerSource `json:"source" validate:"required,oneof=manual telematics"`
+	ReadAt           *time.Time              `json:"readAt"`
+	MeterReplacement bool                    `json:"meterReplacement"`
+}
+
+func (h *Handler) GetTruckOdometerReadings(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
+	}
+
+	opts = opts.Where("truckId", query.Equal, int32(parsedId))
+
+	if len(opts.Sort) == 0 {
+		opts = opts.OrderBy("readAt", true).OrderBy("id", true)
+	}
+
+	readings, total, err := h.readings.List(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(readings) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(readings, opts.BuildMeta(total)))
+}
+
+// AddTruckOdometerReading records a reading, rejected when it is lower than
+// the last one unless meterReplacement is set.
+func (h *Handler) AddTruckOdometerReading(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	request := odometerReadingRequest{}
+
+	if err := helpers.ParseBody(c, &request); err != nil {
+		return err
+	}
+
+	if err := helpers.Validate(request); err != nil {
+		return err
+	}
+
+	reading := entities.OdometerReading{
+		TruckID:          int32(parsedId),
+		Value:            *request.Value,
+		Source:           request.Source,
+		ReadAt:           time.Now(),
+		MeterReplacement: request.MeterReplacement,
+		RecordedBy:       middleware.Actor(c),
+	}
+
+	if request.ReadAt != nil {
+		reading.ReadAt = *request.ReadAt
+	}
+
+	if err := odometer.Record(ctx, h.repo, &reading); err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(helpers.ParseResultToMap(reading))
+}
@@ -0,0 +1,93 @@
+package truck
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/mdelclaro/gobrax/src/services/trip"
+)
+
+func (h *Handler) GetTruckTrips(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
+	}
+
+	opts = opts.Where("truckId", query.Equal, int32(parsedId))
+
+	if len(opts.Sort) == 0 {
+		opts = opts.OrderBy("startedAt", true)
+	}
+
+	trips, total, err := h.trips.List(ctx, opts, entities.TripDriver)
+	if err != nil {
+		return err
+	}
+
+	if len(trips) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(trips, opts.BuildMeta(total)))
+}
+
+func (h *Handler) AddTruckTrip(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	truck := entities.Truck{}
+	newTrip := entities.Trip{}
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	if err := helpers.ParseBody(c, &newTrip); err != nil {
+		return err
+	}
+
+	if err := helpers.Validate(newTrip); err != nil {
+		return err
+	}
+
+	if err := h.repo.FindById(ctx, &truck, int32(parsedId)); err != nil {
+		return err
+	}
+
+	if newTrip.DriverID != nil {
+		driver := entities.Driver{}
+
+		if err := h.repo.FindById(ctx, &driver, *newTrip.DriverID); err != nil {
+			if apperr.Is(err, apperr.NotFound) {
+				return apperr.New(apperr.Validation, "invalid driver provided")
+			}
+
+			return err
+		}
+	}
+
+	newTrip.ID = 0
+	newTrip.TruckID = truck.ID
+	newTrip.Truck = nil
+	newTrip.Driver = nil
+
+	if err := trip.Log(ctx, h.repo, &newTrip); err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(helpers.ParseResultToMap(newTrip))
+}
@@ -1,186 +1,527 @@
 package truck
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
-	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v3"
 	"github.com/mdelclaro/gobrax/src/api/helpers"
-	database "github.com/mdelclaro/gobrax/src/db"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/apperr"
 	"github.com/mdelclaro/gobrax/src/repository/entities"
-	"github.com/mdelclaro/gobrax/src/shared"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/mdelclaro/gobrax/src/repository/typed"
+	"github.com/mdelclaro/gobrax/src/services/apikey"
+	"github.com/mdelclaro/gobrax/src/services/assignment"
+	"github.com/mdelclaro/gobrax/src/services/maintenance"
+	"github.com/mdelclaro/gobrax/src/services/vehicle"
+	"github.com/shopspring/decimal"
 )
 
-func SetupTruckRoutes(router fiber.Router) {
+func SetupTruckRoutes(router fiber.Router, repo interfaces.IRepository) {
+	h := NewHandler(repo)
+
 	truck := router.Group("/truck")
-	truck.Get("/:id", GetTruckByID)
-	truck.Get("/", GetAllTrucks)
-	truck.Post("/", AddTruck)
-	truck.Put("/", UpdateTruck)
-	truck.Delete("/:id", DeleteTruck)
-	truck.Post("/update-driver/:id", UpdateTruckDriver)
+	truck.Get("/:id", h.GetTruckByID, middleware.Authorize(middleware.Viewer, apikey.TrucksRead))
+	truck.Get("/", h.GetAllTrucks, middleware.Authorize(middleware.Viewer, apikey.TrucksRead))
+	truck.Post("/", h.AddTruck, middleware.Authorize(middleware.Dispatcher, apikey.TrucksWrite))
+	truck.Put("/", h.UpdateTruck, middleware.Authorize(middleware.Dispatcher, apikey.TrucksWrite))
+	truck.Patch("/:id", h.PatchTruck, middleware.Authorize(middleware.Dispatcher, apikey.TrucksWrite))
+	truck.Delete("/:id", h.DeleteTruck, middleware.Authorize(middleware.Admin, apikey.TrucksDelete))
+	truck.Post("/update-driver/:id", h.UpdateTruckDriver, middleware.Authorize(middleware.Dispatcher, apikey.AssignmentsWrite))
+	truck.Get("/:id/assignments", h.GetTruckAssignments, middleware.Authorize(middleware.Viewer, apikey.AssignmentsRead))
+	truck.Get("/:id/driver", h.GetTruckDriverAt, middleware.Authorize(middleware.Viewer, apikey.AssignmentsRead))
+	truck.Delete("/:id/driver", h.UnassignTruckDriver, middleware.Authorize(middleware.Dispatcher, apikey.AssignmentsWrite))
+	truck.Post("/:id/move-driver", h.MoveTruckDriver, middleware.Authorize(middleware.Dispatcher, apikey.AssignmentsWrite))
+	truck.Get("/:id/trips", h.GetTruckTrips, middleware.Authorize(middleware.Viewer, apikey.TripsRead))
+	truck.Post("/:id/trips", h.AddTruckTrip, middleware.Authorize(middleware.Dispatcher, apikey.TripsWrite))
+	truck.Get("/:id/odometer", h.GetTruckOdometerReadings, middleware.Authorize(middleware.Viewer, apikey.TrucksRead))
+	truck.Post("/:id/odometer", h.AddTruckOdometerReading, middleware.Authorize(middleware.Dispatcher, apikey.TrucksWrite))
+	truck.Post("/:id/restore", h.RestoreTruck, middleware.Authorize(middleware.Admin, apikey.TrucksDelete))
+}
+
+// patchableFields maps the json fields a merge patch can change to their
+// columns. The driver and the totals have their own endpoints.
+var patchableFields = map[string]string{
+	"licensePlate": "license_plate",
+	"engineHours":  "engine_hours",
+
+	"vin":                     "vin",
+	"make":                    "make",
+	"model":                   "model",
+	"year":                    "year",
+	"axleCount":               "axle_count",
+	"grossVehicleWeight":      "gross_vehicle_weight",
+	"fuelType":                "fuel_type",
+	"tankCapacity":            "tank_capacity",
+	"requiredLicenseCategory": "required_license_category",
+	"vehicleModelId":          "vehicle_model_id",
+}
+
+var errEngineHoursDown = apperr.New(apperr.Validation, "engine hours can't go down")
+
+type Handler struct {
+	repo        interfaces.IRepository
+	trucks      *typed.Repository[entities.Truck]
+	assignments *typed.Repository[entities.TruckAssignment]
+	trips       *typed.Repository[entities.Trip]
+	readings    *typed.Repository[entities.OdometerReading]
 }
 
-func GetAllTrucks(c fiber.Ctx) error {
-	trucks := []entities.Truck{}
+func NewHandler(repo interfaces.IRepository) *Handler {
+	return &Handler{
+		repo:        repo,
+		trucks:      typed.New[entities.Truck](repo),
+		assignments: typed.New[entities.TruckAssignment](repo),
+		trips:       typed.New[entities.Trip](repo),
+		readings:    typed.New[entities.OdometerReading](repo),
+	}
+}
+
+type moveDriverResult struct {
+	From entities.Truck `json:"from"`
+	To   entities.Truck `json:"to"`
+}
+
+func (h *Handler) GetAllTrucks(c fiber.Ctx) error {
+	ctx := c.UserContext()
 
-	if err := shared.InitRepo(database.DB.Db, "Driver").FindAll(&trucks, "Driver"); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
+	}
+
+	trucks, total, err := h.trucks.List(ctx, opts, entities.TruckDriver)
+	if err != nil {
+		return err
 	}
 
 	if len(trucks) == 0 {
 		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
 	}
 
-	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(trucks))
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(trucks, opts.BuildMeta(total)))
 }
 
-func GetTruckByID(c fiber.Ctx) error {
-	truck := entities.Truck{}
+func (h *Handler) GetTruckByID(c fiber.Ctx) error {
+	ctx := c.UserContext()
 
 	id := c.Params("id")
 	parsedId, err := strconv.Atoi(id)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("invalid id provided: %s", err.Error())))
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
 	}
 
-	if err := shared.InitRepo(database.DB.Db, "Driver").FindById(&truck, int32(parsedId), "Driver"); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	truck, err := h.trucks.Get(ctx, int32(parsedId), entities.TruckDriver)
+	if err != nil {
+		return err
 	}
 
-	if truck.ID == 0 {
-		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	truck.NextServiceDue, err = maintenance.NextDue(ctx, h.repo, truck)
+	if err != nil {
+		return err
 	}
 
+	helpers.SetETag(c, truck.Version)
+
 	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(truck))
 }
 
-func AddTruck(c fiber.Ctx) error {
+func (h *Handler) AddTruck(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
 	truck := entities.Truck{}
 
-	if err := json.Unmarshal(c.Body(), &truck); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	if err := helpers.ParseBody(c, &truck); err != nil {
+		return err
 	}
 
-	if err := helpers.Validator.Struct(truck); err != nil {
-		required := ""
+	if err := helpers.Validate(truck); err != nil {
+		return err
+	}
 
-		var valErrs validator.ValidationErrors
-		if errors.As(err, &valErrs) {
+	if truck.DriverID != nil {
+		return apperr.New(apperr.Validation, "can't add driver directly to truck")
+	}
 
-			for i, err := range valErrs {
-				field := err.Field()
-				if i != 0 {
-					field = ", " + field
-				}
+	if !truck.FuelUsed.IsZero() || !truck.DistanceTraveled.IsZero() {
+		return apperr.New(apperr.Validation, "fuel used and distance traveled are derived from trips and odometer readings")
+	}
 
-				required = required + field
-			}
-		}
+	if truck.EngineHours.IsNegative() {
+		return apperr.New(apperr.Validation, "engine hours can't be negative")
+	}
 
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("missing required field(s): %s", required)))
+	if err := vehicle.ApplyModel(ctx, h.repo, &truck); err != nil {
+		return err
 	}
 
-	if truck.DriverID != nil {
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("can't add driver directly to truck")))
+	if err := vehicle.ValidateSpecs(truck.VehicleSpecs); err != nil {
+		return err
 	}
 
-	if err := shared.InitRepo(database.DB.Db).Create(&truck); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	if err := h.repo.Create(ctx, &truck); err != nil {
+		return err
 	}
 
 	return c.Status(http.StatusCreated).JSON(helpers.ParseResultToMap(truck))
 }
 
-func UpdateTruck(c fiber.Ctx) error {
+func (h *Handler) UpdateTruck(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
 	truck := entities.Truck{}
 
-	if err := json.Unmarshal(c.Body(), &truck); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(err.Error())
+	if err := helpers.ParseBody(c, &truck); err != nil {
+		return err
 	}
 
 	if truck.ID == 0 {
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("id is required")))
+		return apperr.New(apperr.Validation, "id is required")
 	}
 
 	if truck.DriverID != nil {
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("can't directly update driver id")))
+		return apperr.New(apperr.Validation, "can't directly update driver id")
+	}
+
+	if !truck.FuelUsed.IsZero() || !truck.DistanceTraveled.IsZero() {
+		return apperr.New(apperr.Validation, "can't directly update fuel used or distance traveled, log a trip or an odometer reading instead")
+	}
+
+	if truck.VIN != nil && !vehicle.ValidVIN(*truck.VIN) {
+		return apperr.New(apperr.Validation, "invalid vin provided")
+	}
+
+	if err := vehicle.ValidateSpecs(truck.VehicleSpecs); err != nil {
+		return err
 	}
 
-	if err := shared.InitRepo(database.DB.Db).Update(&truck); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	// an explicit zero would still be written, clear them so the totals are kept
+	truck.FuelUsed = decimal.Decimal{}
+	truck.DistanceTraveled = decimal.Decimal{}
+	if truck.EngineHours.IsZero() {
+		truck.EngineHours = decimal.Decimal{}
+	}
+
+	err := h.repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		// locked, so the version checked is the one being updated
+		current := entities.Truck{}
+		if err := tx.FindByIdForUpdate(ctx, &current, truck.ID); err != nil {
+			return err
+		}
+
+		if err := helpers.CheckIfMatch(c, current.Version); err != nil {
+			return err
+		}
+
+		if !truck.EngineHours.IsZero() && truck.EngineHours.LessThan(current.EngineHours) {
+			return errEngineHoursDown
+		}
+
+		// specs left out are taken from the model
+		if err := vehicle.ApplyModel(ctx, tx, &truck); err != nil {
+			return err
+		}
+
+		return tx.Update(ctx, &truck)
+	})
+
+	if err != nil {
+		return err
 	}
 
 	// return updated truck with driver association
-	if err := shared.InitRepo(database.DB.Db, "Driver").FindById(&truck, truck.ID, "Driver"); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	if err := h.repo.FindById(ctx, &truck, truck.ID, "Driver"); err != nil {
+		return err
 	}
 
+	helpers.SetETag(c, truck.Version)
+
 	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(truck))
 }
 
-func DeleteTruck(c fiber.Ctx) error {
+// PatchTruck applies a JSON merge patch to a truck.
+func (h *Handler) PatchTruck(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
 	id := c.Params("id")
 	parsedId, err := strconv.Atoi(id)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("invalid id provided: %s", err.Error())))
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	var truck entities.Truck
+
+	err = h.repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		current := entities.Truck{}
+		if err := tx.FindByIdForUpdate(ctx, &current, int32(parsedId)); err != nil {
+			return err
+		}
+
+		if err := helpers.CheckIfMatch(c, current.Version); err != nil {
+			return err
+		}
+
+		engineHours := current.EngineHours
+
+		columns, err := helpers.MergePatch(c, &current, patchableFields)
+		if err != nil {
+			return err
+		}
+
+		if hours, ok := columns["engine_hours"].(decimal.Decimal); ok && hours.LessThan(engineHours) {
+			return errEngineHoursDown
+		}
+
+		// a new model replaces the specs the patch doesn't set itself
+		if modelID, ok := columns["vehicle_model_id"].(*int32); ok && modelID != nil {
+			model := entities.VehicleModel{}
+			if err := tx.FindById(ctx, &model, *modelID); err != nil {
+				return err
+			}
+
+			for column, value := range vehicle.ModelColumns(model, columns) {
+				columns[column] = value
+			}
+		}
+
+		truck, err = typed.New[entities.Truck](tx).Patch(ctx, int32(parsedId), columns, entities.TruckDriver)
+		if err != nil {
+			return err
+		}
+
+		return vehicle.ValidateSpecs(truck.VehicleSpecs)
+	})
+
+	if err != nil {
+		return err
 	}
 
-	if err := shared.InitRepo(database.DB.Db).Delete(entities.Truck{}, int32(parsedId)); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	helpers.SetETag(c, truck.Version)
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(truck))
+}
+
+func (h *Handler) DeleteTruck(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	force := fiber.Query[bool](c, "force")
+
+	err = h.repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		truck := entities.Truck{}
+
+		// locked, so a driver can't be assigned while it is deleted
+		if err := tx.FindByIdForUpdate(ctx, &truck, int32(parsedId)); err != nil {
+			return err
+		}
+
+		if err := helpers.CheckIfMatch(c, truck.Version); err != nil {
+			return err
+		}
+
+		if truck.DriverID != nil && !force {
+			return apperr.New(apperr.Conflict, "truck has driver %d assigned, use force=true to detach it", *truck.DriverID)
+		}
+
+		if truck.DriverID != nil {
+			if err := assignment.Unassign(ctx, tx, &truck, assignment.ReasonDeleted); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(ctx, &entities.Truck{}, int32(parsedId))
+	})
+
+	if err != nil {
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(""))
 }
 
-func UpdateTruckDriver(c fiber.Ctx) error {
+func (h *Handler) RestoreTruck(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
 	truck := entities.Truck{}
-	driver := entities.Driver{}
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	if err := h.repo.Restore(ctx, &truck, int32(parsedId)); err != nil {
+		return err
+	}
+
+	if err := h.repo.FindById(ctx, &truck, int32(parsedId), "Driver"); err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(truck))
+}
+
+func (h *Handler) UpdateTruckDriver(c fiber.Ctx) error {
+	ctx := c.UserContext()
 
 	truckId := c.Params("id")
 	driverId := c.Query("driverId")
 
 	parsedTruckId, err := strconv.Atoi(truckId)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("invalid truck id provided: %s", err.Error())))
+		return apperr.New(apperr.Validation, "invalid truck id provided: %s", err.Error())
+	}
+
+	parsedDriverId, err := strconv.Atoi(driverId)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid driver id provided: %s", err.Error())
 	}
 
-	if err := shared.InitRepo(database.DB.Db).FindById(&truck, int32(parsedTruckId)); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	truck := entities.Truck{GormModel: entities.GormModel{ID: int32(parsedTruckId)}}
+	reason := c.Query("reason", assignment.ReasonAssigned)
+
+	if err := assignment.Assign(ctx, h.repo, &truck, int32(parsedDriverId), reason); err != nil {
+		return err
 	}
 
-	if truck.ID == 0 {
-		return c.Status(http.StatusNotFound).JSON(helpers.BuildError(fmt.Errorf("truck not found")))
+	// return updated truck with driver association
+	if err := h.repo.FindById(ctx, &truck, int32(parsedTruckId), "Driver"); err != nil {
+		return err
 	}
 
-	parsedDriverId, err := strconv.Atoi(driverId)
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(truck))
+}
+
+func (h *Handler) GetTruckAssignments(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("invalid driver id provided: %s", err.Error())))
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
 	}
 
-	if err := shared.InitRepo(database.DB.Db).FindById(&driver, int32(parsedDriverId)); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
 	}
 
-	if driver.ID == 0 || !driver.IsActive {
-		return c.Status(http.StatusBadRequest).JSON(helpers.BuildError(fmt.Errorf("invalid driver provided")))
+	opts = opts.Where("truckId", query.Equal, int32(parsedId))
+
+	if len(opts.Sort) == 0 {
+		opts = opts.OrderBy("startedAt", true)
 	}
 
-	truck.DriverID = &driver.ID
-	truck.Driver = nil
+	assignments, total, err := h.assignments.List(ctx, opts, entities.TruckAssignmentDriver)
+	if err != nil {
+		return err
+	}
 
-	if err := shared.InitRepo(database.DB.Db).Update(&truck); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	if len(assignments) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
 	}
 
-	// return updated truck with driver association
-	if err := shared.InitRepo(database.DB.Db, "Driver").FindById(&truck, int32(parsedTruckId), "Driver"); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(helpers.BuildError(err))
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(assignments, opts.BuildMeta(total)))
+}
+
+func (h *Handler) GetTruckDriverAt(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	at := time.Now()
+	if rawAt := c.Query("at"); rawAt != "" {
+		at, err = time.Parse(time.RFC3339, rawAt)
+		if err != nil {
+			return apperr.New(apperr.Validation, "invalid at provided: %s", err.Error())
+		}
+	}
+
+	current, err := assignment.DriverAt(ctx, h.repo, int32(parsedId), at)
+	if err != nil {
+		return err
+	}
+
+	if current.ID == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(current))
+}
+
+func (h *Handler) UnassignTruckDriver(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	truck := entities.Truck{GormModel: entities.GormModel{ID: int32(parsedId)}}
+	reason := c.Query("reason", assignment.ReasonUnassigned)
+
+	if err := assignment.Unassign(ctx, h.repo, &truck, reason); err != nil {
+		return err
+	}
+
+	// return updated truck as stored
+	if err := h.repo.FindById(ctx, &truck, truck.ID); err != nil {
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(truck))
 }
+
+// MoveTruckDriver moves the driver of a truck to the one given by toTruckId.
+// If the destination already has a driver, swap=true exchanges both drivers.
+func (h *Handler) MoveTruckDriver(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedFromId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid truck id provided: %s", err.Error())
+	}
+
+	parsedToId, err := strconv.Atoi(c.Query("toTruckId"))
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid destination truck id provided: %s", err.Error())
+	}
+
+	if parsedFromId == parsedToId {
+		return apperr.New(apperr.Validation, "can't move driver to the same truck")
+	}
+
+	swap := fiber.Query[bool](c, "swap")
+
+	from := entities.Truck{GormModel: entities.GormModel{ID: int32(parsedFromId)}}
+	to := entities.Truck{GormModel: entities.GormModel{ID: int32(parsedToId)}}
+
+	if err := assignment.Move(ctx, h.repo, &from, &to, swap); err != nil {
+		return err
+	}
+
+	// return both updated trucks with driver association
+	if err := h.repo.FindById(ctx, &from, from.ID, "Driver"); err != nil {
+		return err
+	}
+
+	if err := h.repo.FindById(ctx, &to, to.ID, "Driver"); err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(moveDriverResult{From: from, To: to}))
+}
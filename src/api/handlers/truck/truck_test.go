@@ -2,49 +2,66 @@ package truck
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
 	database "github.com/mdelclaro/gobrax/src/db"
 	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/memory"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/mdelclaro/gobrax/src/services/assignment"
+	"github.com/mdelclaro/gobrax/src/services/trip"
+	"github.com/mdelclaro/gobrax/src/shared"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
-	app *fiber.App
 	db  *sql.DB
 	now       = time.Time{}
 	id  int32 = 1
+
+	otherId int32 = 2
+
+	startedAt = time.Date(2024, 8, 1, 8, 0, 0, 0, time.UTC)
+	endedAt   = time.Date(2024, 8, 1, 18, 0, 0, 0, time.UTC)
+
+	dueDistance       = decimal.NewFromInt(1000)
+	remainingDistance = decimal.NewFromInt(200)
 )
 
-func TestMain(m *testing.M) {
-	app = fiber.New()
-	api := app.Group("/api")
+func setupApp(repo interfaces.IRepository) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: helpers.ErrorHandler,
+	})
+	api := app.Group("/api", middleware.WithClaims(&middleware.Claims{Role: middleware.Admin}))
 
-	SetupTruckRoutes(api)
+	SetupTruckRoutes(api, repo)
 
-	exitCode := m.Run()
-	os.Exit(exitCode)
+	return app
 }
 
 func TestTruckHandlers(t *testing.T) {
 	tests := []struct {
 		name string
 
-		route  string
-		method string
-		body   any
+		route   string
+		method  string
+		body    any
+		headers map[string]string
 
 		expectedCode int
 		expectedBody any
@@ -71,21 +88,32 @@ func TestTruckHandlers(t *testing.T) {
 						},
 						Name:          "driver",
 						LicenseNumber: "123",
-						IsActive:      true,
+						Status:        entities.DriverActive,
 					},
 				}},
+				"meta": query.Meta{
+					Total: 1,
+					Limit: query.DefaultLimit,
+				},
 			},
 			mock: func() {
 				dbConn, _, mock := database.StartDbMock(t)
 				db = dbConn
 
+				count := sqlmock.NewRows([]string{"count"}).AddRow(1)
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM \"trucks\"").WillReturnRows(count)
+
 				trucks := sqlmock.NewRows([]string{
-					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id", "Driver__id", "Driver__name", "Driver__license_number", "Driver__is_active",
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
 				}).
-					AddRow(1, "123", "0", "0", 1, 1, "driver", "123", true)
+					AddRow(1, "123", "0", "0", 1)
 
 				expectedSQL := "SELECT (.+) FROM \"trucks\""
 				mock.ExpectQuery(expectedSQL).WillReturnRows(trucks)
+
+				driver := sqlmock.NewRows([]string{"id", "name", "license_number", "status"}).
+					AddRow(1, "driver", "123", "active")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnRows(driver)
 			},
 		},
 		{
@@ -100,7 +128,7 @@ func TestTruckHandlers(t *testing.T) {
 					},
 					LicensePlate:     "123",
 					FuelUsed:         decimal.NewFromInt(0),
-					DistanceTraveled: decimal.NewFromInt(0),
+					DistanceTraveled: decimal.NewFromInt(800),
 					DriverID:         &id,
 					Driver: &entities.Driver{
 						GormModel: entities.GormModel{
@@ -108,7 +136,14 @@ func TestTruckHandlers(t *testing.T) {
 						},
 						Name:          "driver",
 						LicenseNumber: "123",
-						IsActive:      true,
+						Status:        entities.DriverActive,
+					},
+					NextServiceDue: &entities.ServiceDue{
+						PlanID:            1,
+						Plan:              "oil change",
+						DueDistance:       &dueDistance,
+						RemainingDistance: &remainingDistance,
+						Progress:          decimal.RequireFromString("0.8"),
 					},
 				},
 			},
@@ -117,12 +152,23 @@ func TestTruckHandlers(t *testing.T) {
 				db = dbConn
 
 				trucks := sqlmock.NewRows([]string{
-					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id", "Driver__id", "Driver__name", "Driver__license_number", "Driver__is_active",
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
 				}).
-					AddRow(id, "123", "0", "0", 1, 1, "driver", "123", true)
+					AddRow(id, "123", "0", "800", 1)
 
 				expectedSQL := "SELECT (.+) FROM \"trucks\""
 				mock.ExpectQuery(expectedSQL).WillReturnRows(trucks)
+
+				driver := sqlmock.NewRows([]string{"id", "name", "license_number", "status"}).
+					AddRow(1, "driver", "123", "active")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnRows(driver)
+
+				plans := sqlmock.NewRows([]string{
+					"id", "truck_id", "name", "interval_distance", "interval_engine_hours", "interval_days", "last_service_distance", "last_service_engine_hours",
+				}).
+					AddRow(1, id, "oil change", "1000", "0", 0, "0", "0")
+				mock.ExpectQuery("SELECT count(.+) FROM \"maintenance_plans\"").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.+) FROM \"maintenance_plans\"").WillReturnRows(plans)
 			},
 		},
 		{
@@ -152,6 +198,7 @@ func TestTruckHandlers(t *testing.T) {
 						ID:        id,
 						CreatedAt: now,
 						UpdatedAt: now,
+						Version:   1,
 					},
 					LicensePlate:     "123",
 					FuelUsed:         decimal.NewFromInt(0),
@@ -186,6 +233,7 @@ func TestTruckHandlers(t *testing.T) {
 				FuelUsed:         decimal.NewFromInt(0),
 				DistanceTraveled: decimal.NewFromInt(0),
 			},
+			headers:      map[string]string{"If-Match": `"1"`},
 			expectedCode: 200,
 			expectedBody: map[string]any{
 				"data": entities.Truck{
@@ -193,6 +241,7 @@ func TestTruckHandlers(t *testing.T) {
 						ID:        id,
 						CreatedAt: now,
 						UpdatedAt: now,
+						Version:   2,
 					},
 					LicensePlate:     "456",
 					FuelUsed:         decimal.NewFromInt(0),
@@ -204,7 +253,7 @@ func TestTruckHandlers(t *testing.T) {
 						},
 						Name:          "driver",
 						LicenseNumber: "123",
-						IsActive:      true,
+						Status:        entities.DriverActive,
 					},
 				},
 			},
@@ -212,22 +261,102 @@ func TestTruckHandlers(t *testing.T) {
 				dbConn, _, mock := database.StartDbMock(t)
 				db = dbConn
 
+				mock.ExpectBegin()
+
+				current := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id", "version",
+				}).
+					AddRow(id, "123", "0", "0", 1, 1)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(current)
+
 				expectedSQL := "UPDATE \"trucks\" SET .+"
 				row := sqlmock.NewRows([]string{
-					"id", "created_at", "updated_at", "license_plate", "fuel_used", "distance_traveled",
+					"id", "created_at", "updated_at", "license_plate", "fuel_used", "distance_traveled", "version",
 				}).
-					AddRow(id, now, now, "123", "0", "0")
-				mock.ExpectBegin()
+					AddRow(id, now, now, "456", "0", "0", 2)
 				mock.ExpectQuery(expectedSQL).WillReturnRows(row)
 				mock.ExpectCommit()
 
 				trucks := sqlmock.NewRows([]string{
-					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id", "Driver__id", "Driver__name", "Driver__license_number", "Driver__is_active",
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id", "version",
 				}).
-					AddRow(id, "456", "0", "0", 1, 1, "driver", "123", true)
+					AddRow(id, "456", "0", "0", 1, 2)
 
 				expectedSQL = "SELECT (.+) FROM \"trucks\""
 				mock.ExpectQuery(expectedSQL).WillReturnRows(trucks)
+
+				driver := sqlmock.NewRows([]string{"id", "name", "license_number", "status"}).
+					AddRow(1, "driver", "123", "active")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnRows(driver)
+			},
+		},
+		{
+			name:   "[Conflict] - Test Update Truck With Duplicate License Plate",
+			route:  "/api/truck",
+			method: "PUT",
+			body: entities.Truck{
+				GormModel: entities.GormModel{
+					ID: id,
+				},
+				LicensePlate: "456",
+			},
+			expectedCode: 409,
+			expectedBody: helpers.BuildError(errors.New("Key (license_plate)=(456) already exists.")),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+
+				current := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id", "version",
+				}).
+					AddRow(id, "123", "0", "0", 1, 1)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(current)
+
+				mock.ExpectQuery("UPDATE \"trucks\" SET .+").WillReturnError(&pgconn.PgError{
+					Code:   "23505",
+					Detail: "Key (license_plate)=(456) already exists.",
+				})
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:         "[Validation] - Test Patch Truck With Null License Plate",
+			route:        fmt.Sprintf("/api/truck/%d", id),
+			method:       "PATCH",
+			body:         map[string]any{"licensePlate": nil},
+			headers:      map[string]string{"Content-Type": helpers.MIMEMergePatch},
+			expectedCode: 400,
+			expectedBody: helpers.BuildError(errors.New("licensePlate can't be null")),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+
+				truck := sqlmock.NewRows([]string{"id", "license_plate", "driver_id", "version"}).AddRow(id, "123", nil, 1)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(truck)
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:         "[Unsupported Media Type] - Test Patch Truck",
+			route:        fmt.Sprintf("/api/truck/%d", id),
+			method:       "PATCH",
+			body:         map[string]any{"licensePlate": "456"},
+			headers:      map[string]string{"Content-Type": "text/plain"},
+			expectedCode: 415,
+			expectedBody: helpers.BuildError(errors.New("content type must be " + helpers.MIMEMergePatch)),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+
+				truck := sqlmock.NewRows([]string{"id", "license_plate", "driver_id", "version"}).AddRow(id, "123", nil, 1)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(truck)
+				mock.ExpectRollback()
 			},
 		},
 		{
@@ -242,13 +371,67 @@ func TestTruckHandlers(t *testing.T) {
 				dbConn, _, mock := database.StartDbMock(t)
 				db = dbConn
 
-				expectedSQL := "DELETE FROM \"trucks\" .+"
-
 				mock.ExpectBegin()
+
+				truck := sqlmock.NewRows([]string{"id", "license_plate", "driver_id"}).AddRow(id, "123", nil)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(truck)
+
+				expectedSQL := "UPDATE \"trucks\" SET \"deleted_at\"=.+"
+
 				mock.ExpectExec(expectedSQL).WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 			},
 		},
+		{
+			name:         "[Conflict] - Test Delete Truck With Driver",
+			route:        fmt.Sprintf("/api/truck/%d", id),
+			method:       "DELETE",
+			expectedCode: 409,
+			expectedBody: helpers.BuildError(fmt.Errorf("truck has driver %d assigned, use force=true to detach it", id)),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+
+				truck := sqlmock.NewRows([]string{"id", "license_plate", "driver_id"}).AddRow(id, "123", id)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(truck)
+
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:         "[Success] - Test Restore Truck",
+			route:        fmt.Sprintf("/api/truck/%d/restore", id),
+			method:       "POST",
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": entities.Truck{
+					GormModel: entities.GormModel{
+						ID: id,
+					},
+					LicensePlate:     "123",
+					FuelUsed:         decimal.NewFromInt(0),
+					DistanceTraveled: decimal.NewFromInt(0),
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE \"trucks\" SET \"deleted_at\"=\\$1,\"updated_at\"=\\$2 WHERE id = \\$3 AND deleted_at IS NOT NULL").
+					WithArgs(nil, sqlmock.AnyArg(), id).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+
+				truck := sqlmock.NewRows([]string{
+					"id", "updated_at", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(id, now, "123", "0", "0", nil)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\"").WillReturnRows(truck)
+			},
+		},
 		{
 			name:   "[Success] - Test Update Truck Driver",
 			route:  fmt.Sprintf("/api/truck/update-driver/%v?driverId=%v", id, id),
@@ -280,7 +463,7 @@ func TestTruckHandlers(t *testing.T) {
 						},
 						Name:          "driver",
 						LicenseNumber: "123",
-						IsActive:      true,
+						Status:        entities.DriverActive,
 					},
 				},
 			},
@@ -288,71 +471,1053 @@ func TestTruckHandlers(t *testing.T) {
 				dbConn, _, mock := database.StartDbMock(t)
 				db = dbConn
 
-				// find truck by id
+				mock.ExpectBegin()
+
+				// lock truck
 				truck := sqlmock.NewRows([]string{
-					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id", "Driver__id", "Driver__name", "Driver__license_number", "Driver__is_active",
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
 				}).
-					AddRow(id, "123", "0", "0", 1, 1, "driver", "123", true)
+					AddRow(id, "123", "0", "0", nil)
 
-				expectedSQL := "SELECT (.+) FROM \"trucks\""
+				expectedSQL := "SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE"
 				mock.ExpectQuery(expectedSQL).WillReturnRows(truck)
 
-				// find driver by id
+				// lock driver
 				driver := sqlmock.NewRows([]string{
-					"id", "name", "license_number", "is_active",
+					"id", "name", "license_number", "status",
 				}).
-					AddRow(id, "driver", "123", true)
+					AddRow(id, "driver", "123", "active")
 
-				expectedSQL = "SELECT (.+) FROM \"drivers\""
+				expectedSQL = "SELECT (.+) FROM \"drivers\" (.+) FOR UPDATE"
 				mock.ExpectQuery(expectedSQL).WillReturnRows(driver)
 
+				// check driver isn't on another truck
+				expectedSQL = "SELECT (.+) FROM \"trucks\" WHERE \"trucks\".\"driver_id\" = \\$1"
+				mock.ExpectQuery(expectedSQL).WithArgs(id, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				// find open assignment
+				expectedSQL = "SELECT (.+) FROM \"truck_assignments\" WHERE (.+) IS NULL"
+				mock.ExpectQuery(expectedSQL).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
 				// update truck driver
-				expectedSQL = "UPDATE \"trucks\" SET .+"
-				row := sqlmock.NewRows([]string{
-					"id", "created_at", "updated_at", "license_plate", "fuel_used", "distance_traveled",
-				}).
-					AddRow(id, now, now, "123", "0", "0")
-				mock.ExpectBegin()
-				mock.ExpectQuery(expectedSQL).WillReturnRows(row)
+				expectedSQL = "UPDATE \"trucks\" SET \"driver_id\"=\\$1,\"updated_at\"=\\$2 WHERE id = \\$3"
+				mock.ExpectExec(expectedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+
+				// record assignment
+				expectedSQL = "INSERT INTO \"truck_assignments\" (.+) VALUES (.+)"
+				mock.ExpectQuery(expectedSQL).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
 				mock.ExpectCommit()
 
 				// find updated truck
 				trucks := sqlmock.NewRows([]string{
-					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id", "Driver__id", "Driver__name", "Driver__license_number", "Driver__is_active",
+					"id", "updated_at", "license_plate", "fuel_used", "distance_traveled", "driver_id",
 				}).
-					AddRow(id, "456", "0", "0", 1, 1, "driver", "123", true)
+					AddRow(id, now, "456", "0", "0", 1)
 
 				expectedSQL = "SELECT (.+) FROM \"trucks\""
 				mock.ExpectQuery(expectedSQL).WillReturnRows(trucks)
+
+				driver = sqlmock.NewRows([]string{"id", "name", "license_number", "status"}).
+					AddRow(1, "driver", "123", "active")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnRows(driver)
 			},
 		},
-	}
+		{
+			name:         "[Conflict] - Test Update Truck Driver Assigned To Another Truck",
+			route:        fmt.Sprintf("/api/truck/update-driver/%v?driverId=%v", id, id),
+			method:       "POST",
+			expectedCode: 409,
+			expectedBody: helpers.BuildError(fmt.Errorf("%w: truck %d", assignment.ErrDriverAssigned, 2)),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tt.mock()
-			defer db.Close()
+				mock.ExpectBegin()
 
-			reqBody, err := json.Marshal(tt.body)
-			assert.NoError(t, err)
+				truck := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(id, "123", "0", "0", nil)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(truck)
 
-			bodyReader := bytes.NewReader(reqBody)
+				driver := sqlmock.NewRows([]string{
+					"id", "name", "license_number", "status",
+				}).
+					AddRow(id, "driver", "123", "active")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\" (.+) FOR UPDATE").WillReturnRows(driver)
 
-			req, _ := http.NewRequest(
-				tt.method,
-				tt.route,
-				bodyReader,
-			)
+				other := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(2, "456", "0", "0", id)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" WHERE \"trucks\".\"driver_id\" = \\$1").WillReturnRows(other)
 
-			res, err := app.Test(req, -1)
-			assert.NoError(t, err)
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:         "[Success] - Test Unassign Truck Driver",
+			route:        fmt.Sprintf("/api/truck/%d/driver", id),
+			method:       "DELETE",
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": entities.Truck{
+					GormModel: entities.GormModel{
+						ID: id,
+					},
+					LicensePlate:     "123",
+					FuelUsed:         decimal.NewFromInt(0),
+					DistanceTraveled: decimal.NewFromInt(0),
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
 
-			body, _ := io.ReadAll(res.Body)
-			parsedBody, err := json.Marshal(tt.expectedBody)
-			assert.NoError(t, err)
+				truck := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(id, "123", "0", "0", id)
 
-			assert.Equal(t, string(parsedBody), string(body))
-			assert.Equal(t, tt.expectedCode, res.StatusCode)
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(truck)
+
+				open := sqlmock.NewRows([]string{
+					"id", "truck_id", "driver_id", "started_at", "ended_at", "reason",
+				}).
+					AddRow(1, id, id, startedAt, nil, "assigned")
+				mock.ExpectQuery("SELECT (.+) FROM \"truck_assignments\" WHERE (.+) IS NULL").WillReturnRows(open)
+
+				mock.ExpectQuery("UPDATE \"truck_assignments\" SET (.+)\"ended_at\"=(.+)\"end_reason\"=(.+) RETURNING").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+				mock.ExpectExec("UPDATE \"trucks\" SET \"driver_id\"=\\$1").
+					WithArgs(nil, sqlmock.AnyArg(), id, id).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectCommit()
+
+				truck = sqlmock.NewRows([]string{
+					"id", "updated_at", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(id, now, "123", "0", "0", nil)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\"").WillReturnRows(truck)
+			},
+		},
+		{
+			name:         "[Invalid] - Test Unassign Truck Without Driver",
+			route:        fmt.Sprintf("/api/truck/%d/driver", id),
+			method:       "DELETE",
+			expectedCode: 400,
+			expectedBody: helpers.BuildError(assignment.ErrNoDriver),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				truck := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(id, "123", "0", "0", nil)
+
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(truck)
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:         "[Conflict] - Test Move Truck Driver To Occupied Truck",
+			route:        fmt.Sprintf("/api/truck/%d/move-driver?toTruckId=%d", id, 2),
+			method:       "POST",
+			expectedCode: 409,
+			expectedBody: helpers.BuildError(assignment.ErrTruckOccupied),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+
+				from := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(id, "123", "0", "0", id)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(from)
+
+				to := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(2, "456", "0", "0", 2)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(to)
+
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:         "[Success] - Test Swap Truck Drivers",
+			route:        fmt.Sprintf("/api/truck/%d/move-driver?toTruckId=%d&swap=true", id, 2),
+			method:       "POST",
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": moveDriverResult{
+					From: entities.Truck{
+						GormModel: entities.GormModel{
+							ID: id,
+						},
+						LicensePlate:     "123",
+						FuelUsed:         decimal.NewFromInt(0),
+						DistanceTraveled: decimal.NewFromInt(0),
+						DriverID:         &otherId,
+						Driver: &entities.Driver{
+							GormModel: entities.GormModel{
+								ID: otherId,
+							},
+							Name:          "other",
+							LicenseNumber: "456",
+							Status:        entities.DriverActive,
+						},
+					},
+					To: entities.Truck{
+						GormModel: entities.GormModel{
+							ID: otherId,
+						},
+						LicensePlate:     "456",
+						FuelUsed:         decimal.NewFromInt(0),
+						DistanceTraveled: decimal.NewFromInt(0),
+						DriverID:         &id,
+						Driver: &entities.Driver{
+							GormModel: entities.GormModel{
+								ID: id,
+							},
+							Name:          "driver",
+							LicenseNumber: "123",
+							Status:        entities.DriverActive,
+						},
+					},
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				mock.ExpectBegin()
+
+				// both trucks are locked in id order
+				from := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(id, "123", "0", "0", id)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(from)
+
+				to := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(otherId, "456", "0", "0", otherId)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(to)
+
+				// both drivers are checked against the truck they get
+				for _, driverId := range []int32{id, otherId} {
+					driver := sqlmock.NewRows([]string{"id", "name", "license_number", "status"}).
+						AddRow(driverId, "driver", "123", "active")
+					mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WithArgs(driverId, 1).WillReturnRows(driver)
+				}
+
+				// release both drivers first, so the unique constraint holds
+				for _, truckId := range []int32{id, otherId} {
+					mock.ExpectQuery("SELECT (.+) FROM \"truck_assignments\" WHERE (.+) IS NULL").
+						WithArgs(truckId, 1).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}))
+					mock.ExpectExec("UPDATE \"trucks\" SET \"driver_id\"=\\$1").
+						WithArgs(nil, sqlmock.AnyArg(), truckId, truckId).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+
+				for _, pair := range [][2]int32{{otherId, id}, {id, otherId}} {
+					mock.ExpectExec("UPDATE \"trucks\" SET \"driver_id\"=\\$1").
+						WithArgs(pair[1], sqlmock.AnyArg(), pair[0], pair[0]).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectQuery("INSERT INTO \"truck_assignments\" (.+) VALUES (.+)").
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				}
+
+				mock.ExpectCommit()
+
+				from = sqlmock.NewRows([]string{
+					"id", "updated_at", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(id, now, "123", "0", "0", otherId)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\"").WillReturnRows(from)
+
+				fromDriver := sqlmock.NewRows([]string{"id", "name", "license_number", "status"}).
+					AddRow(otherId, "other", "456", "active")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnRows(fromDriver)
+
+				to = sqlmock.NewRows([]string{
+					"id", "updated_at", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(otherId, now, "456", "0", "0", id)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\"").WillReturnRows(to)
+
+				toDriver := sqlmock.NewRows([]string{"id", "name", "license_number", "status"}).
+					AddRow(id, "driver", "123", "active")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnRows(toDriver)
+			},
+		},
+		{
+			name:         "[Success] - Test Get Truck Assignments",
+			route:        fmt.Sprintf("/api/truck/%d/assignments", id),
+			method:       "GET",
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": []entities.TruckAssignment{{
+					GormModel: entities.GormModel{
+						ID: 1,
+					},
+					TruckID:  id,
+					DriverID: id,
+					Driver: &entities.Driver{
+						GormModel: entities.GormModel{
+							ID: 1,
+						},
+						Name:          "driver",
+						LicenseNumber: "123",
+						Status:        entities.DriverActive,
+					},
+					StartedAt: startedAt,
+					Reason:    "assigned",
+				}},
+				"meta": query.Meta{
+					Total: 1,
+					Limit: query.DefaultLimit,
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				count := sqlmock.NewRows([]string{"count"}).AddRow(1)
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM \"truck_assignments\"").WithArgs(id).WillReturnRows(count)
+
+				assignments := sqlmock.NewRows([]string{
+					"id", "truck_id", "driver_id", "started_at", "ended_at", "reason",
+				}).
+					AddRow(1, id, id, startedAt, nil, "assigned")
+
+				expectedSQL := "SELECT (.+) FROM \"truck_assignments\" WHERE (.+) ORDER BY \"truck_assignments\".\"started_at\" DESC"
+				mock.ExpectQuery(expectedSQL).WillReturnRows(assignments)
+
+				driver := sqlmock.NewRows([]string{
+					"id", "name", "license_number", "status",
+				}).
+					AddRow(id, "driver", "123", "active")
+
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnRows(driver)
+			},
+		},
+		{
+			name:         "[Success] - Test Get Truck Driver At",
+			route:        fmt.Sprintf("/api/truck/%d/driver?at=%s", id, startedAt.Add(time.Hour).Format(time.RFC3339)),
+			method:       "GET",
+			expectedCode: 200,
+			expectedBody: map[string]any{
+				"data": entities.TruckAssignment{
+					GormModel: entities.GormModel{
+						ID: 1,
+					},
+					TruckID:  id,
+					DriverID: id,
+					Driver: &entities.Driver{
+						GormModel: entities.GormModel{
+							ID: 1,
+						},
+						Name:          "driver",
+						LicenseNumber: "123",
+						Status:        entities.DriverActive,
+					},
+					StartedAt: startedAt,
+					EndedAt:   &endedAt,
+					Reason:    "assigned",
+					EndReason: "reassigned",
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				assignment := sqlmock.NewRows([]string{
+					"id", "truck_id", "driver_id", "started_at", "ended_at", "reason", "end_reason",
+				}).
+					AddRow(1, id, id, startedAt, endedAt, "assigned", "reassigned")
+
+				expectedSQL := "SELECT (.+) FROM \"truck_assignments\" WHERE (.+) ORDER BY \"truck_assignments\".\"started_at\" DESC,\"truck_assignments\".\"id\" DESC LIMIT \\$3"
+				mock.ExpectQuery(expectedSQL).WithArgs(id, startedAt.Add(time.Hour), 1).WillReturnRows(assignment)
+
+				driver := sqlmock.NewRows([]string{
+					"id", "name", "license_number", "status",
+				}).
+					AddRow(id, "driver", "123", "active")
+
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnRows(driver)
+			},
+		},
+		{
+			name:         "[Invalid] - Test Get Truck Driver At With Invalid Time",
+			route:        fmt.Sprintf("/api/truck/%d/driver?at=%s", id, "yesterday"),
+			method:       "GET",
+			expectedCode: 400,
+			expectedBody: helpers.BuildError(fmt.Errorf("invalid at provided: %s", errors.New("parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\""))),
+			mock:         func() {},
+		},
+		{
+			name:   "[Success] - Test Add Truck Trip",
+			route:  fmt.Sprintf("/api/truck/%d/trips", id),
+			method: "POST",
+			body: entities.Trip{
+				StartOdometer: decimal.NewFromInt(1000),
+				EndOdometer:   decimal.NewFromInt(1250),
+				FuelLiters:    decimal.RequireFromString("87.5"),
+				StartedAt:     startedAt,
+				EndedAt:       endedAt,
+				Origin:        "Curitiba",
+				Destination:   "Joinville",
+			},
+			expectedCode: 201,
+			expectedBody: map[string]any{
+				"data": entities.Trip{
+					GormModel: entities.GormModel{
+						ID:      1,
+						Version: 1,
+					},
+					TruckID:       id,
+					DriverID:      &id,
+					StartOdometer: decimal.NewFromInt(1000),
+					EndOdometer:   decimal.NewFromInt(1250),
+					Distance:      decimal.NewFromInt(250),
+					FuelLiters:    decimal.RequireFromString("87.5"),
+					StartedAt:     startedAt,
+					EndedAt:       endedAt,
+					Origin:        "Curitiba",
+					Destination:   "Joinville",
+				},
+			},
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				truck := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(id, "123", "0", "0", id)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\"").WillReturnRows(truck)
+
+				mock.ExpectBegin()
+
+				// driver who had the truck when the trip started
+				assignment := sqlmock.NewRows([]string{
+					"id", "truck_id", "driver_id", "started_at", "ended_at", "reason",
+				}).
+					AddRow(1, id, id, startedAt, nil, "assigned")
+				mock.ExpectQuery("SELECT (.+) FROM \"truck_assignments\"").WillReturnRows(assignment)
+
+				driver := sqlmock.NewRows([]string{
+					"id", "name", "license_number", "status",
+				}).
+					AddRow(id, "driver", "123", "active")
+				mock.ExpectQuery("SELECT (.+) FROM \"drivers\"").WillReturnRows(driver)
+
+				row := sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, now, now)
+				mock.ExpectQuery("INSERT INTO \"trips\" (.+) VALUES (.+)").WillReturnRows(row)
+
+				mock.ExpectExec("UPDATE \"trucks\" SET \"fuel_used\"=\"fuel_used\" \\+ \\$1").
+					WithArgs(decimal.RequireFromString("87.5"), sqlmock.AnyArg(), id).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				// first readings of the truck, from the start and the end of the trip
+				truck = sqlmock.NewRows([]string{"id", "license_plate"}).AddRow(id, "123")
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\" (.+) FOR UPDATE").WillReturnRows(truck)
+
+				mock.ExpectQuery("SELECT (.+) FROM \"odometer_readings\"").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				row = sqlmock.NewRows([]string{"id"}).AddRow(1)
+				mock.ExpectQuery("INSERT INTO \"odometer_readings\" (.+) VALUES (.+)").WillReturnRows(row)
+
+				row = sqlmock.NewRows([]string{"id"}).AddRow(2)
+				mock.ExpectQuery("INSERT INTO \"odometer_readings\" (.+) VALUES (.+)").WillReturnRows(row)
+
+				mock.ExpectExec("UPDATE \"trucks\" SET \"distance_traveled\"=\"distance_traveled\" \\+ \\$1").
+					WithArgs(decimal.NewFromInt(250), sqlmock.AnyArg(), id).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "[Invalid] - Test Add Truck Trip With Odometer Going Backwards",
+			route:  fmt.Sprintf("/api/truck/%d/trips", id),
+			method: "POST",
+			body: entities.Trip{
+				StartOdometer: decimal.NewFromInt(1250),
+				EndOdometer:   decimal.NewFromInt(1000),
+				StartedAt:     startedAt,
+				EndedAt:       endedAt,
+			},
+			expectedCode: 400,
+			expectedBody: helpers.BuildError(fmt.Errorf("%w: endOdometer can't be lower than startOdometer", trip.ErrInvalidTrip)),
+			mock: func() {
+				dbConn, _, mock := database.StartDbMock(t)
+				db = dbConn
+
+				truck := sqlmock.NewRows([]string{
+					"id", "license_plate", "fuel_used", "distance_traveled", "driver_id",
+				}).
+					AddRow(id, "123", "0", "0", id)
+				mock.ExpectQuery("SELECT (.+) FROM \"trucks\"").WillReturnRows(truck)
+			},
+		},
+		{
+			name:   "[Invalid] - Test Update Truck Totals Directly",
+			route:  "/api/truck",
+			method: "PUT",
+			body: entities.Truck{
+				GormModel: entities.GormModel{
+					ID: id,
+				},
+				FuelUsed: decimal.NewFromInt(10),
+			},
+			expectedCode: 400,
+			expectedBody: helpers.BuildError(fmt.Errorf("can't directly update fuel used or distance traveled, log a trip or an odometer reading instead")),
+			mock:         func() {},
+		},
+		{
+			name:   "[Invalid] - Missing Id",
+			route:  "/api/truck",
+			method: "PUT",
+			body: entities.Truck{
+				LicensePlate: "123",
+			},
+			expectedCode: 400,
+			expectedBody: helpers.BuildError(fmt.Errorf("id is required")),
+			mock:         func() {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			defer db.Close()
+
+			app := setupApp(shared.InitRepo(database.DB.Db))
+
+			reqBody, err := json.Marshal(tt.body)
+			assert.NoError(t, err)
+
+			bodyReader := bytes.NewReader(reqBody)
+
+			req, _ := http.NewRequest(
+				tt.method,
+				tt.route,
+				bodyReader,
+			)
+
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			body, _ := io.ReadAll(res.Body)
+			parsedBody, err := json.Marshal(tt.expectedBody)
+			assert.NoError(t, err)
+
+			assert.Equal(t, string(parsedBody), string(body))
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+		})
+	}
+}
+
+// runs the assignment flow against the in memory repository, checking the
+// state the handlers leave behind rather than the queries they run
+func TestTruckHandlersInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	driver := entities.Driver{Name: "driver", LicenseNumber: "123", Status: entities.DriverActive}
+	assert.NoError(t, repo.Create(ctx, &driver))
+
+	truck := entities.Truck{LicensePlate: "ABC"}
+	assert.NoError(t, repo.Create(ctx, &truck))
+
+	tests := []struct {
+		name string
+
+		route   string
+		method  string
+		ifMatch string
+
+		expectedCode int
+	}{
+		{
+			name:         "[Success] - Assign Driver",
+			route:        fmt.Sprintf("/api/truck/update-driver/%d?driverId=%d", truck.ID, driver.ID),
+			method:       "POST",
+			expectedCode: 200,
+		},
+		{
+			name:         "[Success] - Get Current Driver",
+			route:        fmt.Sprintf("/api/truck/%d/driver", truck.ID),
+			method:       "GET",
+			expectedCode: 200,
+		},
+		{
+			name:         "[Error] - Delete Truck With Driver",
+			route:        fmt.Sprintf("/api/truck/%d", truck.ID),
+			method:       "DELETE",
+			expectedCode: 409,
+		},
+		{
+			name:         "[Success] - Unassign Driver",
+			route:        fmt.Sprintf("/api/truck/%d/driver", truck.ID),
+			method:       "DELETE",
+			expectedCode: 200,
+		},
+		{
+			name:         "[Error] - Unassign Without Driver",
+			route:        fmt.Sprintf("/api/truck/%d/driver", truck.ID),
+			method:       "DELETE",
+			expectedCode: 400,
+		},
+		{
+			name:         "[Error] - Delete Changed Truck",
+			route:        fmt.Sprintf("/api/truck/%d", truck.ID),
+			method:       "DELETE",
+			ifMatch:      `"1"`,
+			expectedCode: 412,
+		},
+		{
+			// created, assigned and unassigned
+			name:         "[Success] - Delete Truck",
+			route:        fmt.Sprintf("/api/truck/%d", truck.ID),
+			method:       "DELETE",
+			ifMatch:      `"3"`,
+			expectedCode: 200,
+		},
+		{
+			name:         "[Error] - Get Deleted Truck",
+			route:        fmt.Sprintf("/api/truck/%d", truck.ID),
+			method:       "GET",
+			expectedCode: 404,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.route, nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+		})
+	}
+
+	assignments := []entities.TruckAssignment{}
+	total, err := repo.FindAll(ctx, &assignments, query.New())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, assignment.ReasonAssigned, assignments[0].Reason)
+	assert.Equal(t, assignment.ReasonUnassigned, assignments[0].EndReason)
+}
+
+func TestTruckDriverLicenseInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	expired := time.Now().AddDate(0, 0, -1)
+
+	drivers := map[string]*entities.Driver{
+		"ce":      {Name: "ce", LicenseNumber: "1", LicenseCategory: entities.LicenseCE, Status: entities.DriverActive},
+		"b":       {Name: "b", LicenseNumber: "2", LicenseCategory: entities.LicenseB, Status: entities.DriverActive},
+		"expired": {Name: "expired", LicenseNumber: "3", LicenseCategory: entities.LicenseCE, LicenseExpiresAt: &expired, Status: entities.DriverActive},
+	}
+	for _, driver := range drivers {
+		assert.NoError(t, repo.Create(ctx, driver))
+	}
+
+	truck := entities.Truck{LicensePlate: "ABC", VehicleSpecs: entities.VehicleSpecs{RequiredLicenseCategory: entities.LicenseC}}
+	assert.NoError(t, repo.Create(ctx, &truck))
+
+	tests := []struct {
+		name string
+
+		driver string
+
+		expectedCode  int
+		expectedError string
+	}{
+		{
+			name:          "[Error] - Expired License",
+			driver:        "expired",
+			expectedCode:  400,
+			expectedError: "driver license is expired: expired at " + expired.Format(time.DateOnly),
+		},
+		{
+			name:          "[Error] - Category Not Covered",
+			driver:        "b",
+			expectedCode:  400,
+			expectedError: "driver license doesn't cover the truck: category C required",
+		},
+		{
+			name:         "[Success] - Category Covered",
+			driver:       "ce",
+			expectedCode: 200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := fmt.Sprintf("/api/truck/update-driver/%d?driverId=%d", truck.ID, drivers[tt.driver].ID)
+			req, _ := http.NewRequest("POST", route, nil)
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			if tt.expectedError != "" {
+				body, _ := io.ReadAll(res.Body)
+				parsedBody, _ := json.Marshal(helpers.BuildError(errors.New(tt.expectedError)))
+				assert.Equal(t, string(parsedBody), string(body))
+			}
+		})
+	}
+}
+
+func TestTruckSpecsInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	fh := entities.VehicleModel{
+		Make: "Volvo", Model: "FH", Year: 2022,
+		VehicleSpecs: entities.VehicleSpecs{
+			AxleCount:               3,
+			GrossVehicleWeight:      decimal.NewFromInt(26000),
+			FuelType:                entities.FuelDiesel,
+			TankCapacity:            decimal.NewFromInt(700),
+			RequiredLicenseCategory: entities.LicenseC,
+		},
+	}
+	assert.NoError(t, repo.Create(ctx, &fh))
+
+	actros := entities.VehicleModel{
+		Make: "Mercedes-Benz", Model: "Actros", Year: 2019,
+		VehicleSpecs: entities.VehicleSpecs{AxleCount: 2, FuelType: entities.FuelDiesel, TankCapacity: decimal.NewFromInt(400)},
+	}
+	assert.NoError(t, repo.Create(ctx, &actros))
+
+	tests := []struct {
+		name string
+
+		route  string
+		method string
+		body   string
+
+		expectedCode int
+		check        func(t *testing.T, body []byte)
+	}{
+		{
+			name:         "[Invalid] - Add Truck With Bad VIN Checksum",
+			route:        "/api/truck",
+			method:       "POST",
+			body:         `{"licensePlate":"ABC","vin":"1M8GDM9A1KP042788"}`,
+			expectedCode: 400,
+		},
+		{
+			name:         "[Success] - Add Truck From Model",
+			route:        "/api/truck",
+			method:       "POST",
+			body:         fmt.Sprintf(`{"licensePlate":"ABC","vin":"1M8GDM9AXKP042788","vehicleModelId":%d,"tankCapacity":"900"}`, fh.ID),
+			expectedCode: 201,
+			check: func(t *testing.T, body []byte) {
+				var res struct {
+					Data entities.Truck `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(body, &res))
+				assert.Equal(t, "Volvo", res.Data.Make)
+				assert.Equal(t, int32(2022), res.Data.Year)
+				assert.Equal(t, int32(3), res.Data.AxleCount)
+				assert.Equal(t, entities.LicenseC, res.Data.RequiredLicenseCategory)
+				// set on the truck, kept over the model
+				assert.Equal(t, "900", res.Data.TankCapacity.String())
+			},
+		},
+		{
+			name:         "[Conflict] - Add Truck With Same VIN",
+			route:        "/api/truck",
+			method:       "POST",
+			body:         `{"licensePlate":"DEF","vin":"1M8GDM9AXKP042788"}`,
+			expectedCode: 409,
+		},
+		{
+			name:         "[Success] - Add Truck Without Model",
+			route:        "/api/truck",
+			method:       "POST",
+			body:         `{"licensePlate":"GHI","make":"Scania","year":2015,"fuelType":"lng"}`,
+			expectedCode: 201,
+		},
+		{
+			name:         "[Success] - Filter By Specs",
+			route:        "/api/truck?make=Volvo&year>=2020",
+			method:       "GET",
+			expectedCode: 200,
+			check: func(t *testing.T, body []byte) {
+				var res struct {
+					Data []entities.Truck `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(body, &res))
+				assert.Len(t, res.Data, 1)
+				assert.Equal(t, "ABC", res.Data[0].LicensePlate)
+			},
+		},
+		{
+			name:         "[Success] - Patch Model Replaces Specs",
+			route:        "/api/truck/1",
+			method:       "PATCH",
+			body:         fmt.Sprintf(`{"vehicleModelId":%d,"axleCount":4}`, actros.ID),
+			expectedCode: 200,
+			check: func(t *testing.T, body []byte) {
+				var res struct {
+					Data entities.Truck `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(body, &res))
+				assert.Equal(t, "Actros", res.Data.Model)
+				assert.Equal(t, int32(4), res.Data.AxleCount)
+				assert.Equal(t, "400", res.Data.TankCapacity.String())
+				assert.Equal(t, entities.LicenseCategory(""), res.Data.RequiredLicenseCategory)
+			},
+		},
+		{
+			name:         "[Invalid] - Patch Negative Tank Capacity",
+			route:        "/api/truck/1",
+			method:       "PATCH",
+			body:         `{"tankCapacity":"-1"}`,
+			expectedCode: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.route, bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			if tt.check != nil {
+				body, _ := io.ReadAll(res.Body)
+				tt.check(t, body)
+			}
+		})
+	}
+}
+
+// The steps run in order against the same truck, each reading is checked
+// against the ones accepted before it.
+func TestTruckOdometerInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	// the total the truck had before its readings is kept
+	truck := entities.Truck{LicensePlate: "ABC", DistanceTraveled: decimal.NewFromInt(120000)}
+	assert.NoError(t, repo.Create(ctx, &truck))
+
+	now := time.Now().UTC()
+	dayAgo := now.AddDate(0, 0, -1)
+
+	odometerRoute := fmt.Sprintf("/api/truck/%d/odometer", truck.ID)
+	tripsRoute := fmt.Sprintf("/api/truck/%d/trips", truck.ID)
+
+	tests := []struct {
+		name string
+
+		route string
+		body  any
+
+		expectedCode     int
+		expectedError    string
+		expectedDistance string
+	}{
+		{
+			name:             "[Success] - First Reading",
+			route:            odometerRoute,
+			body:             map[string]any{"value": "1000", "source": "manual", "readAt": now.Add(-2 * time.Hour)},
+			expectedCode:     201,
+			expectedDistance: "120000",
+		},
+		{
+			name:             "[Success] - Higher Reading",
+			route:            odometerRoute,
+			body:             map[string]any{"value": "1200", "source": "telematics", "readAt": now.Add(-90 * time.Minute)},
+			expectedCode:     201,
+			expectedDistance: "120200",
+		},
+		{
+			name:             "[Invalid] - Lower Reading",
+			route:            odometerRoute,
+			body:             map[string]any{"value": "1100", "source": "manual"},
+			expectedCode:     400,
+			expectedError:    "odometer reading is lower than the last one: last one was 1200",
+			expectedDistance: "120200",
+		},
+		{
+			name:             "[Invalid] - Older Reading",
+			route:            odometerRoute,
+			body:             map[string]any{"value": "1300", "source": "manual", "readAt": dayAgo},
+			expectedCode:     400,
+			expectedDistance: "120200",
+		},
+		{
+			name:             "[Invalid] - Trip Close Source",
+			route:            odometerRoute,
+			body:             map[string]any{"value": "1300", "source": "trip_close"},
+			expectedCode:     400,
+			expectedDistance: "120200",
+		},
+		{
+			name:             "[Success] - Meter Replacement",
+			route:            odometerRoute,
+			body:             map[string]any{"value": "50", "source": "manual", "meterReplacement": true, "readAt": now.Add(-time.Hour)},
+			expectedCode:     201,
+			expectedDistance: "120200",
+		},
+		{
+			name:  "[Success] - Trip Adds Its Reading",
+			route: tripsRoute,
+			body: map[string]any{
+				"startOdometer": "60", "endOdometer": "300",
+				"startedAt": now.Add(-50 * time.Minute), "endedAt": now.Add(-40 * time.Minute),
+			},
+			expectedCode:     201,
+			expectedDistance: "120450",
+		},
+		{
+			name:  "[Success] - Trip Before Last Reading Is Covered",
+			route: tripsRoute,
+			body: map[string]any{
+				"startOdometer": "1000", "endOdometer": "1100",
+				"startedAt": dayAgo, "endedAt": dayAgo.Add(time.Hour),
+			},
+			expectedCode:     201,
+			expectedDistance: "120450",
+		},
+		{
+			name:             "[Success] - Reading On Replaced Meter",
+			route:            odometerRoute,
+			body:             map[string]any{"value": "400", "source": "telematics"},
+			expectedCode:     201,
+			expectedDistance: "120550",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBody, err := json.Marshal(tt.body)
+			assert.NoError(t, err)
+
+			req, _ := http.NewRequest("POST", tt.route, bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			if tt.expectedError != "" {
+				body, _ := io.ReadAll(res.Body)
+				parsedBody, _ := json.Marshal(helpers.BuildError(errors.New(tt.expectedError)))
+				assert.Equal(t, string(parsedBody), string(body))
+			}
+
+			stored := entities.Truck{}
+			assert.NoError(t, repo.FindById(ctx, &stored, truck.ID))
+			assert.Equal(t, tt.expectedDistance, stored.DistanceTraveled.String())
+		})
+	}
+
+	readings := []entities.OdometerReading{}
+	total, err := repo.FindAll(ctx, &readings, query.New().Where("truckId", query.Equal, truck.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+}
+
+// the plate and vin of a deleted truck can be used by a new one, which then
+// keeps the deleted one from being restored
+func TestTruckReuseDeletedPlateInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	vin := "1M8GDM9AXKP042788"
+
+	deleted := entities.Truck{LicensePlate: "ABC1234", VIN: &vin}
+	assert.NoError(t, repo.Create(ctx, &deleted))
+	assert.NoError(t, repo.Delete(ctx, &entities.Truck{}, deleted.ID))
+
+	tests := []struct {
+		name string
+
+		method string
+		route  string
+		body   any
+
+		expectedCode  int
+		expectedError string
+	}{
+		{
+			name:         "[Success] - Add Truck With Plate Of Deleted Truck",
+			method:       "POST",
+			route:        "/api/truck",
+			body:         map[string]any{"licensePlate": "ABC1234", "vin": vin},
+			expectedCode: 201,
+		},
+		{
+			name:          "[Conflict] - Add Truck With Plate In Use",
+			method:        "POST",
+			route:         "/api/truck",
+			body:          map[string]any{"licensePlate": "ABC1234"},
+			expectedCode:  409,
+			expectedError: "Key (license_plate)=(ABC1234) already exists.",
+		},
+		{
+			name:          "[Conflict] - Restore Truck With Plate In Use",
+			method:        "POST",
+			route:         fmt.Sprintf("/api/truck/%d/restore", deleted.ID),
+			expectedCode:  409,
+			expectedError: "Key (license_plate)=(ABC1234) already exists.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBody, err := json.Marshal(tt.body)
+			assert.NoError(t, err)
+
+			req, _ := http.NewRequest(tt.method, tt.route, bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			if tt.expectedError != "" {
+				body, _ := io.ReadAll(res.Body)
+				parsedBody, _ := json.Marshal(helpers.BuildError(errors.New(tt.expectedError)))
+				assert.Equal(t, string(parsedBody), string(body))
+			}
 		})
 	}
 }
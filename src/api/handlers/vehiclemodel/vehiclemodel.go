@@ -0,0 +1,146 @@
+package vehiclemodel
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/mdelclaro/gobrax/src/repository/typed"
+	"github.com/mdelclaro/gobrax/src/services/apikey"
+	"github.com/mdelclaro/gobrax/src/services/vehicle"
+)
+
+func SetupVehicleModelRoutes(router fiber.Router, repo interfaces.IRepository) {
+	h := NewHandler(repo)
+
+	model := router.Group("/vehicle-model")
+	model.Get("/:id", h.GetVehicleModelByID, middleware.Authorize(middleware.Viewer, apikey.TrucksRead))
+	model.Get("/", h.GetAllVehicleModels, middleware.Authorize(middleware.Viewer, apikey.TrucksRead))
+	model.Post("/", h.AddVehicleModel, middleware.Authorize(middleware.Dispatcher, apikey.TrucksWrite))
+	model.Delete("/:id", h.DeleteVehicleModel, middleware.Authorize(middleware.Admin, apikey.TrucksDelete))
+}
+
+type Handler struct {
+	repo   interfaces.IRepository
+	models *typed.Repository[entities.VehicleModel]
+}
+
+func NewHandler(repo interfaces.IRepository) *Handler {
+	return &Handler{
+		repo:   repo,
+		models: typed.New[entities.VehicleModel](repo),
+	}
+}
+
+func (h *Handler) GetAllVehicleModels(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	opts, err := helpers.ParseQueryOptions(c)
+	if err != nil {
+		return err
+	}
+
+	models, total, err := h.models.List(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(models) == 0 {
+		return c.Status(http.StatusNoContent).JSON(helpers.ParseResultToMap(""))
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultWithMeta(models, opts.BuildMeta(total)))
+}
+
+func (h *Handler) GetVehicleModelByID(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	model, err := h.models.Get(ctx, int32(parsedId))
+	if err != nil {
+		return err
+	}
+
+	helpers.SetETag(c, model.Version)
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(model))
+}
+
+func (h *Handler) AddVehicleModel(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	model := entities.VehicleModel{}
+
+	if err := helpers.ParseBody(c, &model); err != nil {
+		return err
+	}
+
+	if err := helpers.Validate(model); err != nil {
+		return err
+	}
+
+	if err := vehicle.ValidateSpecs(model.VehicleSpecs); err != nil {
+		return err
+	}
+
+	if err := h.repo.Create(ctx, &model); err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(helpers.ParseResultToMap(model))
+}
+
+// DeleteVehicleModel removes a model no truck references anymore. Trucks
+// keep the specs copied from it either way.
+func (h *Handler) DeleteVehicleModel(c fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	parsedId, err := strconv.Atoi(id)
+	if err != nil {
+		return apperr.New(apperr.Validation, "invalid id provided: %s", err.Error())
+	}
+
+	err = h.repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		model := entities.VehicleModel{}
+		if err := tx.FindByIdForUpdate(ctx, &model, int32(parsedId)); err != nil {
+			return err
+		}
+
+		if err := helpers.CheckIfMatch(c, model.Version); err != nil {
+			return err
+		}
+
+		opts := query.New().Where("vehicleModelId", query.Equal, model.ID)
+		opts.Limit = 1
+
+		trucks := []entities.Truck{}
+		used, err := tx.FindAll(ctx, &trucks, opts)
+		if err != nil {
+			return err
+		}
+
+		if used > 0 {
+			return apperr.New(apperr.Conflict, "vehicle model is used by %d trucks", used)
+		}
+
+		return tx.Delete(ctx, &entities.VehicleModel{}, model.ID)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(""))
+}
@@ -0,0 +1,115 @@
+package vehiclemodel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupApp(repo interfaces.IRepository) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: helpers.ErrorHandler,
+	})
+	api := app.Group("/api", middleware.WithClaims(&middleware.Claims{Role: middleware.Admin}))
+
+	SetupVehicleModelRoutes(api, repo)
+
+	return app
+}
+
+func TestVehicleModelInMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	app := setupApp(repo)
+
+	used := entities.VehicleModel{Make: "Volvo", Model: "FH", Year: 2022}
+	assert.NoError(t, repo.Create(ctx, &used))
+
+	unused := entities.VehicleModel{Make: "Scania", Model: "R", Year: 2018}
+	assert.NoError(t, repo.Create(ctx, &unused))
+
+	truck := entities.Truck{LicensePlate: "ABC", VehicleModelID: &used.ID}
+	assert.NoError(t, repo.Create(ctx, &truck))
+
+	tests := []struct {
+		name string
+
+		route  string
+		method string
+		body   string
+
+		expectedCode  int
+		expectedError string
+	}{
+		{
+			name:          "[Invalid] - Add Model With Negative Tank Capacity",
+			route:         "/api/vehicle-model",
+			method:        "POST",
+			body:          `{"make":"DAF","model":"XF","tankCapacity":"-10"}`,
+			expectedCode:  400,
+			expectedError: "invalid vehicle specs: tankCapacity can't be negative",
+		},
+		{
+			name:         "[Success] - Add Model",
+			route:        "/api/vehicle-model",
+			method:       "POST",
+			body:         `{"make":"DAF","model":"XF","year":2021,"axleCount":2,"fuelType":"diesel","tankCapacity":"600"}`,
+			expectedCode: 201,
+		},
+		{
+			name:         "[Success] - Filter Models",
+			route:        "/api/vehicle-model?make=DAF",
+			method:       "GET",
+			expectedCode: 200,
+		},
+		{
+			name:          "[Conflict] - Delete Model In Use",
+			route:         fmt.Sprintf("/api/vehicle-model/%d", used.ID),
+			method:        "DELETE",
+			expectedCode:  409,
+			expectedError: "vehicle model is used by 1 trucks",
+		},
+		{
+			name:         "[Success] - Delete Unused Model",
+			route:        fmt.Sprintf("/api/vehicle-model/%d", unused.ID),
+			method:       "DELETE",
+			expectedCode: 200,
+		},
+		{
+			name:         "[Not Found] - Get Deleted Model",
+			route:        fmt.Sprintf("/api/vehicle-model/%d", unused.ID),
+			method:       "GET",
+			expectedCode: 404,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.route, bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			if tt.expectedError != "" {
+				body, _ := io.ReadAll(res.Body)
+				parsedBody, _ := json.Marshal(helpers.BuildError(errors.New(tt.expectedError)))
+				assert.Equal(t, string(parsedBody), string(body))
+			}
+		})
+	}
+}
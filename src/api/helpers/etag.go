@@ -0,0 +1,40 @@
+package helpers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/apperr"
+)
+
+// ETag is the strong entity tag of a record version, e.g. "3".
+func ETag(version int32) string {
+	return strconv.Quote(strconv.Itoa(int(version)))
+}
+
+// SetETag sets the ETag header of the response to the given version.
+func SetETag(c fiber.Ctx, version int32) {
+	c.Set(fiber.HeaderETag, ETag(version))
+}
+
+// CheckIfMatch fails with PreconditionFailed when the request has an
+// If-Match header and none of its tags is the current version. Requests
+// without the header aren't checked.
+func CheckIfMatch(c fiber.Ctx, version int32) error {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return nil
+	}
+
+	current := ETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		// weak tags never match, If-Match uses the strong comparison
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return nil
+		}
+	}
+
+	return apperr.New(apperr.PreconditionFailed, "record has changed, current version is %s", current)
+}
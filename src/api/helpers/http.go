@@ -1,8 +1,32 @@
 package helpers
 
-import "github.com/go-playground/validator"
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
 
-var Validator = validator.New()
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+)
+
+// StatusClientClosedRequest is the non standard status, borrowed from nginx,
+// for requests abandoned before a response could be written.
+const StatusClientClosedRequest = 499
+
+var statusByKind = map[apperr.Kind]int{
+	apperr.NotFound:           http.StatusNotFound,
+	apperr.Conflict:           http.StatusConflict,
+	apperr.Validation:         http.StatusBadRequest,
+	apperr.Unauthorized:       http.StatusUnauthorized,
+	apperr.Forbidden:          http.StatusForbidden,
+	apperr.Internal:           http.StatusInternalServerError,
+	apperr.PreconditionFailed: http.StatusPreconditionFailed,
+	apperr.Canceled:           StatusClientClosedRequest,
+	apperr.Timeout:            http.StatusGatewayTimeout,
+}
 
 type ErrorResponse struct {
 	Error string `json:"error,omitempty"`
@@ -12,6 +36,10 @@ func ParseResultToMap(result any) map[string]any {
 	return map[string]any{"data": result}
 }
 
+func ParseResultWithMeta(result any, meta query.Meta) map[string]any {
+	return map[string]any{"data": result, "meta": meta}
+}
+
 func BuildError(err error) map[string]any {
 	errResponse := &ErrorResponse{
 		Error: err.Error(),
@@ -19,3 +47,50 @@ func BuildError(err error) map[string]any {
 
 	return ParseResultToMap(errResponse)
 }
+
+// ParseBody decodes the json body of the request into target.
+func ParseBody(c fiber.Ctx, target any) error {
+	if err := json.Unmarshal(c.Body(), target); err != nil {
+		return apperr.New(apperr.Validation, "invalid body provided: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Validate checks the validate tags of target.
+func Validate(target any) error {
+	if err := Validator.Struct(target); err != nil {
+		return apperr.Wrap(apperr.Validation, err)
+	}
+
+	return nil
+}
+
+// StatusOf maps an error to the http status it is reported with.
+func StatusOf(err error) int {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+
+	return statusByKind[apperr.KindOf(err)]
+}
+
+// ErrorHandler is the app wide error handler, so handlers and middlewares can
+// return errors and have them reported in the same envelope and status.
+func ErrorHandler(c fiber.Ctx, err error) error {
+	var valErrs validator.ValidationErrors
+	if errors.As(err, &valErrs) {
+		return c.Status(http.StatusBadRequest).JSON(BuildValidationError(valErrs))
+	}
+
+	status := StatusOf(err)
+
+	// the cause of internal errors is logged, not leaked to clients
+	if status == http.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Method(), c.Path(), err)
+		err = errors.New(http.StatusText(status))
+	}
+
+	return c.Status(status).JSON(BuildError(err))
+}
@@ -0,0 +1,115 @@
+package helpers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/apperr"
+)
+
+const MIMEMergePatch = "application/merge-patch+json"
+
+// MergePatch applies the RFC 7396 merge patch in the request body on top of
+// target, a pointer to the stored record, validates the result and returns
+// the columns to write, zero values and nulls included.
+//
+// patchable maps the json name of each field clients may patch to its
+// column, any other field in the patch is rejected.
+func MergePatch(c fiber.Ctx, target any, patchable map[string]string) (map[string]any, error) {
+	contentType, _, _ := strings.Cut(c.Get(fiber.HeaderContentType), ";")
+	if contentType = strings.TrimSpace(contentType); contentType != MIMEMergePatch && contentType != fiber.MIMEApplicationJSON {
+		return nil, fiber.NewError(http.StatusUnsupportedMediaType, "content type must be "+MIMEMergePatch)
+	}
+
+	patch := map[string]json.RawMessage{}
+	if err := json.Unmarshal(c.Body(), &patch); err != nil || patch == nil {
+		return nil, apperr.New(apperr.Validation, "invalid body provided: merge patch must be a json object")
+	}
+
+	keys := make([]string, 0, len(patch))
+	for key := range patch {
+		if _, ok := patchable[key]; !ok {
+			return nil, apperr.New(apperr.Validation, "%s can't be patched", key)
+		}
+
+		keys = append(keys, key)
+	}
+
+	// report errors in the same order between requests
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field, ok := fieldByJSONName(reflect.ValueOf(target).Elem(), key)
+		if !ok {
+			return nil, apperr.New(apperr.Internal, "unknown patchable field %s", key)
+		}
+
+		if string(patch[key]) == "null" && field.Kind() != reflect.Ptr {
+			return nil, apperr.New(apperr.Validation, "%s can't be null", key)
+		}
+	}
+
+	current, err := json.Marshal(target)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.Internal, err)
+	}
+
+	merged := map[string]json.RawMessage{}
+	if err := json.Unmarshal(current, &merged); err != nil {
+		return nil, apperr.Wrap(apperr.Internal, err)
+	}
+
+	for _, key := range keys {
+		merged[key] = patch[key]
+	}
+
+	document, err := json.Marshal(merged)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.Internal, err)
+	}
+
+	// decoded into a new record, so nulls clear the fields they are set to
+	patched := reflect.New(reflect.TypeOf(target).Elem())
+	if err := json.Unmarshal(document, patched.Interface()); err != nil {
+		return nil, apperr.New(apperr.Validation, "invalid body provided: %s", err.Error())
+	}
+
+	if err := Validate(patched.Interface()); err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]any, len(keys))
+	for _, key := range keys {
+		value, _ := fieldByJSONName(patched.Elem(), key)
+		columns[patchable[key]] = value.Interface()
+	}
+
+	return columns, nil
+}
+
+// fieldByJSONName finds the field of a struct, embedded ones included,
+// named name in json.
+func fieldByJSONName(value reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if found, ok := fieldByJSONName(value.Field(i), name); ok {
+				return found, true
+			}
+
+			continue
+		}
+
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == name {
+			return value.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
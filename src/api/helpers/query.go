@@ -0,0 +1,161 @@
+package helpers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+)
+
+var reservedParams = map[string]bool{
+	"limit":          true,
+	"cursor":         true,
+	"page":           true,
+	"pageSize":       true,
+	"sort":           true,
+	"includeDeleted": true,
+}
+
+// filter operators are written as a suffix of the param name, so
+// `licensePlate~=ABC` arrives as key `licensePlate~` and value `ABC`
+var operatorSuffixes = map[byte]query.Operator{
+	'!': query.NotEqual,
+	'~': query.Like,
+	'>': query.GreaterOrEqual,
+	'<': query.LessOrEqual,
+}
+
+// ParseQueryOptions reads pagination (limit/cursor or page/pageSize),
+// sorting (sort=field,-field), includeDeleted and field filters from the
+// request query.
+// Params listed in ignore are left for the handler to read.
+func ParseQueryOptions(c fiber.Ctx, ignore ...string) (query.Options, error) {
+	opts := query.New()
+
+	limit, err := parsePositiveInt(c, "limit")
+	if err != nil {
+		return opts, err
+	}
+
+	pageSize, err := parsePositiveInt(c, "pageSize")
+	if err != nil {
+		return opts, err
+	}
+
+	page, err := parsePositiveInt(c, "page")
+	if err != nil {
+		return opts, err
+	}
+
+	cursor := c.Query("cursor")
+
+	if (page != 0 || pageSize != 0) && (limit != 0 || cursor != "") {
+		return opts, apperr.New(apperr.Validation, "limit/cursor and page/pageSize can't be combined")
+	}
+
+	if pageSize != 0 {
+		limit = pageSize
+	}
+
+	if limit != 0 {
+		opts.Limit = limit
+	}
+
+	if opts.Limit > query.MaxLimit {
+		return opts, apperr.New(apperr.Validation, "limit can't be greater than %d", query.MaxLimit)
+	}
+
+	if cursor != "" {
+		offset, err := query.DecodeCursor(cursor)
+		if err != nil {
+			return opts, err
+		}
+
+		opts.Offset = offset
+	}
+
+	if page != 0 || pageSize != 0 {
+		if page == 0 {
+			page = 1
+		}
+
+		opts.Page = page
+		opts.Offset = (page - 1) * opts.Limit
+	}
+
+	if includeDeleted := c.Query("includeDeleted"); includeDeleted != "" {
+		parsed, err := strconv.ParseBool(includeDeleted)
+		if err != nil {
+			return opts, apperr.New(apperr.Validation, "invalid includeDeleted provided")
+		}
+
+		opts.IncludeDeleted = parsed
+	}
+
+	if sortParam := c.Query("sort"); sortParam != "" {
+		for _, field := range strings.Split(sortParam, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+
+			if field == "" {
+				return opts, apperr.New(apperr.Validation, "invalid sort provided")
+			}
+
+			opts = opts.OrderBy(field, desc)
+		}
+	}
+
+	ignored := map[string]bool{}
+	for _, key := range ignore {
+		ignored[key] = true
+	}
+
+	params := c.Queries()
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if !reservedParams[key] && !ignored[key] {
+			keys = append(keys, key)
+		}
+	}
+
+	// keep the generated SQL stable between requests
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := params[key]
+
+		op := query.Equal
+		if key != "" {
+			if suffix, ok := operatorSuffixes[key[len(key)-1]]; ok {
+				op = suffix
+				key = key[:len(key)-1]
+			}
+		}
+
+		if key == "" {
+			return opts, apperr.New(apperr.Validation, "invalid filter provided")
+		}
+
+		opts = opts.Where(strings.Clone(key), op, strings.Clone(value))
+	}
+
+	return opts, nil
+}
+
+func parsePositiveInt(c fiber.Ctx, key string) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 {
+		return 0, apperr.New(apperr.Validation, "invalid %s provided", key)
+	}
+
+	return parsed, nil
+}
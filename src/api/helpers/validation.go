@@ -0,0 +1,124 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"github.com/mdelclaro/gobrax/src/services/vehicle"
+)
+
+const CodeValidationFailed = "validation_failed"
+
+var Validator = newValidator()
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Code   string       `json:"code"`
+	Fields []FieldError `json:"fields"`
+}
+
+type ValidationErrorResponse struct {
+	Error ValidationError `json:"error"`
+}
+
+// BuildValidationError reports every failed rule of a Validator.Struct error
+// by the json name of its field. Any other error is built as usual.
+func BuildValidationError(err error) map[string]any {
+	var valErrs validator.ValidationErrors
+	if !errors.As(err, &valErrs) {
+		return BuildError(err)
+	}
+
+	fields := make([]FieldError, 0, len(valErrs))
+	for _, fieldErr := range valErrs {
+		fields = append(fields, FieldError{
+			Field:   fieldErr.Field(),
+			Rule:    fieldErr.Tag(),
+			Message: message(fieldErr),
+		})
+	}
+
+	return ParseResultToMap(ValidationErrorResponse{
+		Error: ValidationError{
+			Code:   CodeValidationFailed,
+			Fields: fields,
+		},
+	})
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// report fields the way clients send them instead of the go names
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+
+		if name == "" {
+			return field.Name
+		}
+
+		return name
+	})
+
+	v.RegisterValidation("vin", func(fl validator.FieldLevel) bool {
+		return vehicle.ValidVIN(fl.Field().String())
+	})
+
+	return v
+}
+
+func message(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min", "max":
+		bound := "at least"
+		if fieldErr.Tag() == "max" {
+			bound = "at most"
+		}
+
+		switch fieldErr.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("must have %s %s %s", bound, fieldErr.Param(), plural(fieldErr.Param(), "character"))
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Sprintf("must have %s %s %s", bound, fieldErr.Param(), plural(fieldErr.Param(), "item"))
+		default:
+			return fmt.Sprintf("must be %s %s", bound, fieldErr.Param())
+		}
+	case "len":
+		return fmt.Sprintf("must have length %s", fieldErr.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fieldErr.Param())
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", fieldErr.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fieldErr.Param())
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s", fieldErr.Param())
+	case "vin":
+		return "must be a valid VIN"
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(fieldErr.Param()), ", "))
+	default:
+		return fmt.Sprintf("failed %s validation", fieldErr.Tag())
+	}
+}
+
+func plural(count string, noun string) string {
+	if count == "1" {
+		return noun
+	}
+
+	return noun + "s"
+}
@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	database "github.com/mdelclaro/gobrax/src/db"
+	"github.com/mdelclaro/gobrax/src/repository"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/memory"
+	"github.com/mdelclaro/gobrax/src/services/apikey"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	secret     = []byte("secret")
+	privateKey *rsa.PrivateKey
+)
+
+func TestMain(m *testing.M) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	privateKey = key
+
+	exitCode := m.Run()
+	os.Exit(exitCode)
+}
+
+func setupApp(repo interfaces.IRepository) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: helpers.ErrorHandler,
+	})
+	api := app.Group("/api", Authenticate(AuthConfig{
+		HMACSecret:   secret,
+		RSAPublicKey: &privateKey.PublicKey,
+	}, repo))
+
+	ok := func(c fiber.Ctx) error {
+		return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(ClaimsFrom(c).Role))
+	}
+
+	api.Get("/resource", ok, RequireRole(Viewer))
+	api.Delete("/resource", ok, RequireRole(Admin))
+	api.Get("/trucks", func(c fiber.Ctx) error {
+		return c.Status(http.StatusOK).JSON(helpers.ParseResultToMap(ApiKeyFrom(c).Name))
+	}, Authorize(Viewer, apikey.TrucksRead))
+	api.Get("/trips", ok, Authorize(Viewer, apikey.TripsRead))
+
+	return app
+}
+
+func sign(method jwt.SigningMethod, key any, role Role, expiresAt time.Time) string {
+	token, err := jwt.NewWithClaims(method, Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}).SignedString(key)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return token
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	later := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name string
+
+		method string
+		token  string
+
+		expectedCode int
+		expectedBody any
+	}{
+		{
+			name:         "[Unauthorized] - Test Missing Token",
+			method:       "GET",
+			expectedCode: 401,
+			expectedBody: helpers.BuildError(errors.New("missing bearer token")),
+		},
+		{
+			name:         "[Unauthorized] - Test Wrong Secret",
+			method:       "GET",
+			token:        sign(jwt.SigningMethodHS256, []byte("other"), Viewer, later),
+			expectedCode: 401,
+			expectedBody: helpers.BuildError(errors.New("invalid token: token signature is invalid: signature is invalid")),
+		},
+		{
+			name:         "[Unauthorized] - Test Expired Token",
+			method:       "GET",
+			token:        sign(jwt.SigningMethodHS256, secret, Viewer, time.Now().Add(-time.Hour)),
+			expectedCode: 401,
+			expectedBody: helpers.BuildError(errors.New("invalid token: token has invalid claims: token is expired")),
+		},
+		{
+			name:         "[Forbidden] - Test Unknown Role",
+			method:       "GET",
+			token:        sign(jwt.SigningMethodHS256, secret, "driver", later),
+			expectedCode: 403,
+			expectedBody: helpers.BuildError(errors.New("unknown role: driver")),
+		},
+		{
+			name:         "[Success] - Test HS256 Viewer Reads",
+			method:       "GET",
+			token:        sign(jwt.SigningMethodHS256, secret, Viewer, later),
+			expectedCode: 200,
+			expectedBody: helpers.ParseResultToMap(Viewer),
+		},
+		{
+			name:         "[Forbidden] - Test Dispatcher Deletes",
+			method:       "DELETE",
+			token:        sign(jwt.SigningMethodHS256, secret, Dispatcher, later),
+			expectedCode: 403,
+			expectedBody: helpers.BuildError(errors.New("admin role required")),
+		},
+		{
+			name:         "[Success] - Test RS256 Admin Deletes",
+			method:       "DELETE",
+			token:        sign(jwt.SigningMethodRS256, privateKey, Admin, later),
+			expectedCode: 200,
+			expectedBody: helpers.ParseResultToMap(Admin),
+		},
+	}
+
+	app := setupApp(memory.NewRepository())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, "/api/resource", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			body, _ := io.ReadAll(res.Body)
+			parsedBody, err := json.Marshal(tt.expectedBody)
+			assert.NoError(t, err)
+
+			assert.Equal(t, string(parsedBody), string(body))
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+		})
+	}
+}
+
+func TestApiKeyMiddleware(t *testing.T) {
+	secretSum := sha256.Sum256([]byte("secret"))
+	secretHash := hex.EncodeToString(secretSum[:])
+	now := time.Now()
+
+	keyRows := func(revokedAt *time.Time) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "prefix", "secret_hash", "scopes", "last_used_at", "revoked_at"}).
+			AddRow(1, "ingester", "abc123", secretHash, "trucks:read,trips:write", now, revokedAt)
+	}
+
+	tests := []struct {
+		name string
+
+		route string
+		key   string
+
+		expectedCode int
+		expectedBody any
+
+		mock func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:         "[Unauthorized] - Test Malformed Key",
+			route:        "/api/trucks",
+			key:          "gbx_abc123",
+			expectedCode: 401,
+			expectedBody: helpers.BuildError(apikey.ErrInvalidKey),
+			mock:         func(mock sqlmock.Sqlmock) {},
+		},
+		{
+			name:         "[Unauthorized] - Test Unknown Key",
+			route:        "/api/trucks",
+			key:          "gbx_abc123.secret",
+			expectedCode: 401,
+			expectedBody: helpers.BuildError(apikey.ErrInvalidKey),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM \"api_keys\"").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name:         "[Unauthorized] - Test Wrong Secret",
+			route:        "/api/trucks",
+			key:          "gbx_abc123.other",
+			expectedCode: 401,
+			expectedBody: helpers.BuildError(apikey.ErrInvalidKey),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM \"api_keys\"").WillReturnRows(keyRows(nil))
+			},
+		},
+		{
+			name:         "[Unauthorized] - Test Revoked Key",
+			route:        "/api/trucks",
+			key:          "gbx_abc123.secret",
+			expectedCode: 401,
+			expectedBody: helpers.BuildError(errors.New("invalid api key: revoked")),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM \"api_keys\"").WillReturnRows(keyRows(&now))
+			},
+		},
+		{
+			name:         "[Forbidden] - Test Missing Scope",
+			route:        "/api/trips",
+			key:          "gbx_abc123.secret",
+			expectedCode: 403,
+			expectedBody: helpers.BuildError(errors.New("trips:read scope required")),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM \"api_keys\"").WillReturnRows(keyRows(nil))
+			},
+		},
+		{
+			name:         "[Success] - Test Scoped Read",
+			route:        "/api/trucks",
+			key:          "gbx_abc123.secret",
+			expectedCode: 200,
+			expectedBody: helpers.ParseResultToMap("ingester"),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM \"api_keys\"").WillReturnRows(keyRows(nil))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, gormDb, mock := database.StartDbMock(t)
+			defer db.Close()
+
+			tt.mock(mock)
+
+			app := setupApp(repository.NewRepository(gormDb))
+
+			req, _ := http.NewRequest("GET", tt.route, nil)
+			req.Header.Set(HeaderApiKey, tt.key)
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			body, _ := io.ReadAll(res.Body)
+			parsedBody, err := json.Marshal(tt.expectedBody)
+			assert.NoError(t, err)
+
+			assert.Equal(t, string(parsedBody), string(body))
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// QueryTimeout bounds the context handlers hand to the repository, so the
+// queries of a request are canceled once it has run for longer than timeout.
+// A zero timeout leaves requests unbounded.
+func QueryTimeout(timeout time.Duration) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if timeout <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
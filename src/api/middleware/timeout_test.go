@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryTimeout(t *testing.T) {
+	repo := memory.NewRepository()
+
+	tests := []struct {
+		name string
+
+		timeout time.Duration
+		delay   time.Duration
+
+		expectedCode int
+	}{
+		{
+			name:         "[Success] - Test Within Timeout",
+			timeout:      time.Second,
+			expectedCode: 404,
+		},
+		{
+			name:         "[Error] - Test Timed Out",
+			timeout:      10 * time.Millisecond,
+			delay:        50 * time.Millisecond,
+			expectedCode: 504,
+		},
+		{
+			name:         "[Success] - Test Disabled",
+			delay:        20 * time.Millisecond,
+			expectedCode: 404,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{
+				ErrorHandler: helpers.ErrorHandler,
+			})
+			app.Use(QueryTimeout(tt.timeout))
+
+			app.Get("/driver", func(c fiber.Ctx) error {
+				time.Sleep(tt.delay)
+
+				return repo.FindById(c.UserContext(), &entities.Driver{}, 1)
+			})
+
+			req, _ := http.NewRequest("GET", "/driver", nil)
+
+			res, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+		})
+	}
+}
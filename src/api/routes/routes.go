@@ -3,13 +3,27 @@ package routes
 import (
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/logger"
+	"github.com/mdelclaro/gobrax/src/api/handlers/alert"
+	"github.com/mdelclaro/gobrax/src/api/handlers/analytics"
+	"github.com/mdelclaro/gobrax/src/api/handlers/apikey"
 	"github.com/mdelclaro/gobrax/src/api/handlers/driver"
+	"github.com/mdelclaro/gobrax/src/api/handlers/fuel"
+	"github.com/mdelclaro/gobrax/src/api/handlers/maintenance"
 	"github.com/mdelclaro/gobrax/src/api/handlers/truck"
+	"github.com/mdelclaro/gobrax/src/api/handlers/vehiclemodel"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
 )
 
-func SetUpRoutes(app *fiber.App) {
-	api := app.Group("/api", logger.New())
+func SetUpRoutes(app *fiber.App, authCfg middleware.AuthConfig, repo interfaces.IRepository) {
+	api := app.Group("/api", logger.New(), middleware.Authenticate(authCfg, repo))
 
-	driver.SetupDriverRoutes(api)
-	truck.SetupTruckRoutes(api)
+	driver.SetupDriverRoutes(api, repo)
+	truck.SetupTruckRoutes(api, repo)
+	vehiclemodel.SetupVehicleModelRoutes(api, repo)
+	analytics.SetupAnalyticsRoutes(api, repo)
+	apikey.SetupApiKeyRoutes(api, repo)
+	maintenance.SetupMaintenanceRoutes(api, repo)
+	fuel.SetupFuelRoutes(api, repo)
+	alert.SetupAlertRoutes(api, repo)
 }
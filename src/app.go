@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
@@ -12,14 +13,26 @@ import (
 )
 
 func main() {
-	database.StartDb()
-	shared.InitRepo(database.DB.Db)
-	app := utils.SetupApp()
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal("Failed to load config. \n", err)
+	}
+
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := utils.RunMigrate(cfg.Database, args[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	database.StartDb(cfg.Database)
+	app := utils.SetupApp(cfg, shared.InitRepo(database.DB.Db))
 
 	app.Use(cors.New())
 	app.Use(func(c fiber.Ctx) error {
 		return fiber.ErrNotFound
 	})
 
-	log.Fatal(app.Listen(config.GetEnv("APP_PORT")))
+	log.Fatal(app.Listen(cfg.Server.Address()))
 }
@@ -0,0 +1,79 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Kind classifies an error by what the caller did wrong, if anything, so
+// every layer can report it without knowing about HTTP.
+type Kind string
+
+const (
+	NotFound     Kind = "not_found"
+	Conflict     Kind = "conflict"
+	Validation   Kind = "validation"
+	Unauthorized Kind = "unauthorized"
+	Forbidden    Kind = "forbidden"
+	Internal     Kind = "internal"
+
+	// the record changed since the client last read it
+	PreconditionFailed Kind = "precondition_failed"
+
+	// the work was abandoned before it finished, because the request was
+	// canceled or ran out of time
+	Canceled Kind = "canceled"
+	Timeout  Kind = "timeout"
+)
+
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New builds an error of the given kind, formatting it like fmt.Errorf.
+func New(kind Kind, format string, args ...any) *Error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap classifies err, keeping its message.
+func Wrap(kind Kind, err error) *Error {
+	return &Error{Kind: kind, Err: err}
+}
+
+// KindOf returns the kind of the outermost typed error in the chain, errors
+// that were never classified are Internal.
+func KindOf(err error) Kind {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed.Kind
+	}
+
+	return Internal
+}
+
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
+
+// FromContext classifies the error of a canceled or expired context, which
+// says nothing about the request itself, and returns any other error as is.
+func FromContext(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return Wrap(Canceled, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(Timeout, err)
+	default:
+		return err
+	}
+}
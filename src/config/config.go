@@ -1,17 +1,204 @@
 package config
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
-func GetEnv(key string) string {
-	err := godotenv.Load(".env")
-	if err != nil {
-		fmt.Print("Error loading .env file")
+type Config struct {
+	Server   Server   `yaml:"server"`
+	Database Database `yaml:"database"`
+	Auth     Auth     `yaml:"auth"`
+}
+
+type Server struct {
+	Port         string        `yaml:"port"`
+	ReadTimeout  time.Duration `yaml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+}
+
+type Database struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslMode"`
+	// QueryTimeout bounds the time a request can spend querying, 0 disables it
+	QueryTimeout time.Duration `yaml:"queryTimeout"`
+}
+
+type Auth struct {
+	JWTSecret        string `yaml:"jwtSecret"`
+	JWTPublicKeyFile string `yaml:"jwtPublicKeyFile"`
+	JWTIssuer        string `yaml:"jwtIssuer"`
+	JWTAudience      string `yaml:"jwtAudience"`
+}
+
+// field binds a setting to its env var and flag, the flag is named after the
+// yaml path, e.g. database.host is -database-host.
+type field struct {
+	key      string
+	env      string
+	usage    string
+	required bool
+	// *string or *time.Duration
+	value any
+}
+
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:         ":3000",
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		},
+		Database: Database{
+			Host:         "localhost",
+			Port:         "5432",
+			SSLMode:      "disable",
+			QueryTimeout: 5 * time.Second,
+		},
+	}
+}
+
+// Load builds the config from, in increasing precedence: defaults, the yaml
+// file given by -config or CONFIG_FILE, the environment (plus the .env file
+// given by -env-file, if it exists) and the flags in args.
+// It returns the args left after the flags, such as a subcommand.
+func Load(args []string) (Config, []string, error) {
+	cfg := Default()
+	fields := cfg.fields()
+
+	flags := flag.NewFlagSet("gobrax", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "yaml config file")
+	envFile := flags.String("env-file", ".env", "env file, skipped when missing")
+
+	overrides := map[string]string{}
+	for _, f := range fields {
+		key := f.key
+		flags.Func(strings.ReplaceAll(key, ".", "-"), f.usage, func(raw string) error {
+			overrides[key] = raw
+			return nil
+		})
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return cfg, nil, fmt.Errorf("invalid flags: %w", err)
+	}
+
+	if *configFile != "" {
+		raw, err := os.ReadFile(*configFile)
+		if err != nil {
+			return cfg, nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		if err := yaml.Unmarshal(raw, &cfg); err != nil {
+			return cfg, nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+
+	// variables already in the environment take precedence over the file
+	if err := godotenv.Load(*envFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return cfg, nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	for _, f := range fields {
+		if raw, ok := os.LookupEnv(f.env); ok {
+			if err := f.set(strings.TrimSpace(raw)); err != nil {
+				return cfg, nil, err
+			}
+		}
+
+		if raw, ok := overrides[f.key]; ok {
+			if err := f.set(raw); err != nil {
+				return cfg, nil, err
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, nil, err
+	}
+
+	return cfg, flags.Args(), nil
+}
+
+// Validate reports every missing required setting at once.
+func (c *Config) Validate() error {
+	missing := []string{}
+
+	for _, f := range c.fields() {
+		if value, ok := f.value.(*string); ok && f.required && *value == "" {
+			missing = append(missing, fmt.Sprintf("%s (%s)", f.key, f.env))
+		}
+	}
+
+	if c.Auth.JWTSecret == "" && c.Auth.JWTPublicKeyFile == "" {
+		missing = append(missing, "auth.jwtSecret (JWT_SECRET) or auth.jwtPublicKeyFile (JWT_PUBLIC_KEY_FILE)")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required config: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// Address is the port in the host:port form expected by Listen.
+func (s Server) Address() string {
+	if strings.Contains(s.Port, ":") {
+		return s.Port
+	}
+
+	return ":" + s.Port
+}
+
+func (d Database) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password='%s' dbname=%s port=%s sslmode=%s", d.Host, d.User, d.Password, d.Name, d.Port, d.SSLMode)
+}
+
+func (c *Config) fields() []field {
+	return []field{
+		{key: "server.port", env: "APP_PORT", usage: "port the api listens on", required: true, value: &c.Server.Port},
+		{key: "server.readTimeout", env: "APP_READ_TIMEOUT", usage: "max duration to read a request", value: &c.Server.ReadTimeout},
+		{key: "server.writeTimeout", env: "APP_WRITE_TIMEOUT", usage: "max duration to write a response", value: &c.Server.WriteTimeout},
+		{key: "database.host", env: "DB_HOST", usage: "database host", required: true, value: &c.Database.Host},
+		{key: "database.port", env: "DB_PORT", usage: "database port", required: true, value: &c.Database.Port},
+		{key: "database.user", env: "DB_USER", usage: "database user", required: true, value: &c.Database.User},
+		{key: "database.password", env: "DB_PASSWORD", usage: "database password", value: &c.Database.Password},
+		{key: "database.name", env: "DB_NAME", usage: "database name", required: true, value: &c.Database.Name},
+		{key: "database.sslMode", env: "DB_SSLMODE", usage: "database sslmode", value: &c.Database.SSLMode},
+		{key: "database.queryTimeout", env: "DB_QUERY_TIMEOUT", usage: "max duration a request can spend querying, 0 disables it", value: &c.Database.QueryTimeout},
+		{key: "auth.jwtSecret", env: "JWT_SECRET", usage: "HS256 token secret", value: &c.Auth.JWTSecret},
+		{key: "auth.jwtPublicKeyFile", env: "JWT_PUBLIC_KEY_FILE", usage: "RS256 PEM public key file", value: &c.Auth.JWTPublicKeyFile},
+		{key: "auth.jwtIssuer", env: "JWT_ISSUER", usage: "expected token issuer", value: &c.Auth.JWTIssuer},
+		{key: "auth.jwtAudience", env: "JWT_AUDIENCE", usage: "expected token audience", value: &c.Auth.JWTAudience},
+	}
+}
+
+func (f field) set(raw string) error {
+	switch target := f.value.(type) {
+	case *string:
+		*target = raw
+	case *time.Duration:
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s provided: %w", f.key, err)
+		}
+
+		*target = parsed
 	}
 
-	return os.Getenv(key)
+	return nil
 }
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlFile := filepath.Join(dir, "config.yaml")
+	err := os.WriteFile(yamlFile, []byte(`
+server:
+  port: ":8080"
+  readTimeout: 30s
+database:
+  host: yaml-host
+  user: yaml-user
+  name: yaml-db
+auth:
+  jwtSecret: yaml-secret
+`), 0o644)
+	assert.NoError(t, err)
+
+	envFile := filepath.Join(dir, ".env")
+	err = os.WriteFile(envFile, []byte("DB_USER= file-user\nDB_NAME= file-db\nJWT_SECRET= file-secret\n"), 0o644)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+
+		args []string
+		env  map[string]string
+
+		expected     func(cfg *Config)
+		expectedArgs []string
+		expectedErr  error
+	}{
+		{
+			name: "[Success] - Test Load Defaults And Env File",
+			args: []string{"-env-file", envFile},
+			expected: func(cfg *Config) {
+				cfg.Database.User = "file-user"
+				cfg.Database.Name = "file-db"
+				cfg.Auth.JWTSecret = "file-secret"
+			},
+			expectedArgs: []string{},
+		},
+		{
+			name: "[Success] - Test Env Overrides Yaml And Flags Override Env",
+			args: []string{"-config", yamlFile, "-env-file", filepath.Join(dir, "missing.env"), "-database-host", "flag-host", "migrate", "up"},
+			env: map[string]string{
+				"DB_HOST":           "env-host",
+				"DB_USER":           "env-user",
+				"APP_WRITE_TIMEOUT": "1m",
+			},
+			expected: func(cfg *Config) {
+				cfg.Server.Port = ":8080"
+				cfg.Server.ReadTimeout = 30 * time.Second
+				cfg.Server.WriteTimeout = time.Minute
+				cfg.Database.Host = "flag-host"
+				cfg.Database.User = "env-user"
+				cfg.Database.Name = "yaml-db"
+				cfg.Auth.JWTSecret = "yaml-secret"
+			},
+			expectedArgs: []string{"migrate", "up"},
+		},
+		{
+			name:        "[Invalid] - Test Missing Required Settings",
+			args:        []string{"-env-file", filepath.Join(dir, "missing.env")},
+			expectedErr: errors.New("missing required config: database.user (DB_USER), database.name (DB_NAME), auth.jwtSecret (JWT_SECRET) or auth.jwtPublicKeyFile (JWT_PUBLIC_KEY_FILE)"),
+		},
+		{
+			name: "[Invalid] - Test Invalid Duration",
+			args: []string{"-env-file", envFile},
+			env: map[string]string{
+				"APP_READ_TIMEOUT": "soon",
+			},
+			expectedErr: errors.New("invalid server.readTimeout provided: time: invalid duration \"soon\""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, f := range (&Config{}).fields() {
+				t.Setenv(f.env, "")
+				os.Unsetenv(f.env)
+			}
+
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, args, err := Load(tt.args)
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+				return
+			}
+
+			expected := Default()
+			tt.expected(&expected)
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, cfg)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
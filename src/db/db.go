@@ -2,13 +2,12 @@ package database
 
 import (
 	"database/sql"
-	"fmt"
 	"log"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/mdelclaro/gobrax/src/config"
-	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/db/migrations"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -20,34 +19,31 @@ type Dbinstance struct {
 
 var DB Dbinstance
 
-func StartDb() Dbinstance {
+// StartDb connects to the database and refuses to go on when its schema is
+// behind the migrations embedded in the binary.
+func StartDb(cfg config.Database) Dbinstance {
 	if DB.Db != nil {
 		return DB
 	}
 
-	// host := config.GetEnv("DB_HOST")
-	host := "db"
-	user := config.GetEnv("DB_USER")
-	pwd := config.GetEnv("DB_PASSWORD")
-	dbName := config.GetEnv("DB_NAME")
-	port := config.GetEnv("DB_PORT")
-
-	dsn := fmt.Sprintf("host=%s user=%s password='%s' dbname=%s port=%s sslmode=disable", host, user, pwd, dbName, port)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
-
+	db, err := Open(cfg)
 	if err != nil {
 		log.Fatal("Failed to connect to database. \n", err)
 	}
 
-	db.Logger = logger.Default.LogMode(logger.Info)
+	all, err := migrations.All()
+	if err != nil {
+		log.Fatal("Failed to load migrations. \n", err)
+	}
 
-	db.AutoMigrate(
-		&entities.Driver{},
-		&entities.Truck{},
-	)
+	pending, err := migrations.NewMigrator(db, all).Pending()
+	if err != nil {
+		log.Fatal("Failed to check migrations. \n", err)
+	}
+
+	if len(pending) > 0 {
+		log.Fatalf("Database schema is %d migration(s) behind, run `migrate up` before starting the app.", len(pending))
+	}
 
 	DB = Dbinstance{
 		Db: db,
@@ -56,6 +52,14 @@ func StartDb() Dbinstance {
 	return DB
 }
 
+// Open connects to the database without checking its schema, for the
+// migrate command.
+func Open(cfg config.Database) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+}
+
 func StartDbMock(t *testing.T) (*sql.DB, *gorm.DB, sqlmock.Sqlmock) {
 	sqldb, mock, err := sqlmock.New()
 	if err != nil {
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS trips;
+DROP TABLE IF EXISTS truck_assignments;
+DROP TABLE IF EXISTS trucks;
+DROP TABLE IF EXISTS drivers;
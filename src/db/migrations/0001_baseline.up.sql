@@ -0,0 +1,93 @@
+-- Baseline of the schema previously created by AutoMigrate. Tables are only
+-- created when missing, so databases set up before migrations adopt it as is.
+
+CREATE TABLE IF NOT EXISTS drivers (
+    id serial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    license_number text,
+    is_active boolean,
+    CONSTRAINT uni_drivers_license_number UNIQUE (license_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_drivers_deleted_at ON drivers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS trucks (
+    id serial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    license_plate text,
+    fuel_used text,
+    distance_traveled text,
+    driver_id integer,
+    CONSTRAINT uni_trucks_license_plate UNIQUE (license_plate),
+    CONSTRAINT uni_trucks_driver_id UNIQUE (driver_id),
+    CONSTRAINT fk_trucks_driver FOREIGN KEY (driver_id) REFERENCES drivers (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trucks_deleted_at ON trucks (deleted_at);
+
+CREATE TABLE IF NOT EXISTS truck_assignments (
+    id serial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    truck_id integer NOT NULL,
+    driver_id integer NOT NULL,
+    started_at timestamptz NOT NULL,
+    ended_at timestamptz,
+    reason text,
+    end_reason text,
+    CONSTRAINT fk_truck_assignments_truck FOREIGN KEY (truck_id) REFERENCES trucks (id),
+    CONSTRAINT fk_truck_assignments_driver FOREIGN KEY (driver_id) REFERENCES drivers (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_truck_assignments_deleted_at ON truck_assignments (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_truck_assignments_truck_id ON truck_assignments (truck_id);
+CREATE INDEX IF NOT EXISTS idx_truck_assignments_driver_id ON truck_assignments (driver_id);
+CREATE INDEX IF NOT EXISTS idx_truck_assignments_started_at ON truck_assignments (started_at);
+CREATE INDEX IF NOT EXISTS idx_truck_assignments_ended_at ON truck_assignments (ended_at);
+
+CREATE TABLE IF NOT EXISTS trips (
+    id serial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    truck_id integer NOT NULL,
+    driver_id integer,
+    start_odometer text,
+    end_odometer text,
+    distance text,
+    fuel_liters text,
+    started_at timestamptz NOT NULL,
+    ended_at timestamptz NOT NULL,
+    origin text,
+    destination text,
+    CONSTRAINT fk_trips_truck FOREIGN KEY (truck_id) REFERENCES trucks (id),
+    CONSTRAINT fk_trips_driver FOREIGN KEY (driver_id) REFERENCES drivers (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trips_deleted_at ON trips (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_trips_truck_id ON trips (truck_id);
+CREATE INDEX IF NOT EXISTS idx_trips_driver_id ON trips (driver_id);
+CREATE INDEX IF NOT EXISTS idx_trips_started_at ON trips (started_at);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id serial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    prefix text NOT NULL,
+    secret_hash text NOT NULL,
+    scopes text NOT NULL,
+    expires_at timestamptz,
+    last_used_at timestamptz,
+    revoked_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
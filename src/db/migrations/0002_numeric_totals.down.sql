@@ -0,0 +1,18 @@
+ALTER TABLE trips
+    DROP CONSTRAINT IF EXISTS chk_trips_period,
+    DROP CONSTRAINT IF EXISTS chk_trips_fuel_liters,
+    DROP CONSTRAINT IF EXISTS chk_trips_odometer,
+    ALTER COLUMN start_odometer TYPE text,
+    ALTER COLUMN end_odometer TYPE text,
+    ALTER COLUMN distance TYPE text,
+    ALTER COLUMN fuel_liters TYPE text;
+
+ALTER TABLE trucks
+    DROP CONSTRAINT IF EXISTS chk_trucks_distance_traveled,
+    DROP CONSTRAINT IF EXISTS chk_trucks_fuel_used,
+    ALTER COLUMN distance_traveled DROP NOT NULL,
+    ALTER COLUMN distance_traveled DROP DEFAULT,
+    ALTER COLUMN distance_traveled TYPE text,
+    ALTER COLUMN fuel_used DROP NOT NULL,
+    ALTER COLUMN fuel_used DROP DEFAULT,
+    ALTER COLUMN fuel_used TYPE text;
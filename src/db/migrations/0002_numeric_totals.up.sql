@@ -0,0 +1,21 @@
+-- AutoMigrate stored decimals as text, which can't be summed in place or
+-- checked. Convert them to numeric and forbid negative values.
+
+ALTER TABLE trucks
+    ALTER COLUMN fuel_used TYPE numeric USING COALESCE(NULLIF(fuel_used, ''), '0')::numeric,
+    ALTER COLUMN fuel_used SET DEFAULT 0,
+    ALTER COLUMN fuel_used SET NOT NULL,
+    ALTER COLUMN distance_traveled TYPE numeric USING COALESCE(NULLIF(distance_traveled, ''), '0')::numeric,
+    ALTER COLUMN distance_traveled SET DEFAULT 0,
+    ALTER COLUMN distance_traveled SET NOT NULL,
+    ADD CONSTRAINT chk_trucks_fuel_used CHECK (fuel_used >= 0),
+    ADD CONSTRAINT chk_trucks_distance_traveled CHECK (distance_traveled >= 0);
+
+ALTER TABLE trips
+    ALTER COLUMN start_odometer TYPE numeric USING COALESCE(NULLIF(start_odometer, ''), '0')::numeric,
+    ALTER COLUMN end_odometer TYPE numeric USING COALESCE(NULLIF(end_odometer, ''), '0')::numeric,
+    ALTER COLUMN distance TYPE numeric USING COALESCE(NULLIF(distance, ''), '0')::numeric,
+    ALTER COLUMN fuel_liters TYPE numeric USING COALESCE(NULLIF(fuel_liters, ''), '0')::numeric,
+    ADD CONSTRAINT chk_trips_odometer CHECK (start_odometer >= 0 AND end_odometer >= start_odometer),
+    ADD CONSTRAINT chk_trips_fuel_liters CHECK (fuel_liters >= 0),
+    ADD CONSTRAINT chk_trips_period CHECK (ended_at > started_at);
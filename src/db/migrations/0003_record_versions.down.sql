@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS trg_api_keys_version ON api_keys;
+DROP TRIGGER IF EXISTS trg_trips_version ON trips;
+DROP TRIGGER IF EXISTS trg_truck_assignments_version ON truck_assignments;
+DROP TRIGGER IF EXISTS trg_trucks_version ON trucks;
+DROP TRIGGER IF EXISTS trg_drivers_version ON drivers;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS version;
+ALTER TABLE trips DROP COLUMN IF EXISTS version;
+ALTER TABLE truck_assignments DROP COLUMN IF EXISTS version;
+ALTER TABLE trucks DROP COLUMN IF EXISTS version;
+ALTER TABLE drivers DROP COLUMN IF EXISTS version;
+
+DROP FUNCTION IF EXISTS bump_version();
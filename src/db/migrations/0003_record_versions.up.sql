@@ -0,0 +1,26 @@
+-- Every record carries a version, exposed as its ETag. It is bumped by a
+-- trigger on each update, so no writer can forget it.
+
+CREATE OR REPLACE FUNCTION bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE drivers ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE trucks ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE truck_assignments ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE trips ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE api_keys ADD COLUMN version integer NOT NULL DEFAULT 1;
+
+CREATE TRIGGER trg_drivers_version BEFORE UPDATE ON drivers
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER trg_trucks_version BEFORE UPDATE ON trucks
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER trg_truck_assignments_version BEFORE UPDATE ON truck_assignments
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER trg_trips_version BEFORE UPDATE ON trips
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER trg_api_keys_version BEFORE UPDATE ON api_keys
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS driver_status_changes;
+
+ALTER TABLE drivers ADD COLUMN is_active boolean;
+
+UPDATE drivers SET is_active = status = 'active';
+
+ALTER TABLE drivers
+    DROP CONSTRAINT IF EXISTS chk_drivers_status,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,35 @@
+-- is_active becomes a status, changed through transitions recorded with who
+-- made them and why.
+
+ALTER TABLE drivers ADD COLUMN status text NOT NULL DEFAULT 'onboarding';
+
+-- an inactive driver was either never active or taken off duty, suspended
+-- keeps them out of trucks until someone reviews it
+UPDATE drivers SET status = CASE WHEN is_active THEN 'active' ELSE 'suspended' END;
+
+ALTER TABLE drivers
+    DROP COLUMN is_active,
+    ADD CONSTRAINT chk_drivers_status
+        CHECK (status IN ('onboarding', 'active', 'suspended', 'on_leave', 'terminated'));
+
+CREATE TABLE driver_status_changes (
+    id serial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    version integer NOT NULL DEFAULT 1,
+    driver_id integer NOT NULL,
+    "from" text NOT NULL,
+    "to" text NOT NULL,
+    reason text NOT NULL,
+    changed_by text,
+    changed_at timestamptz NOT NULL,
+    CONSTRAINT fk_driver_status_changes_driver FOREIGN KEY (driver_id) REFERENCES drivers (id)
+);
+
+CREATE INDEX idx_driver_status_changes_deleted_at ON driver_status_changes (deleted_at);
+CREATE INDEX idx_driver_status_changes_driver_id ON driver_status_changes (driver_id);
+CREATE INDEX idx_driver_status_changes_changed_at ON driver_status_changes (changed_at);
+
+CREATE TRIGGER trg_driver_status_changes_version BEFORE UPDATE ON driver_status_changes
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
@@ -0,0 +1,13 @@
+ALTER TABLE trucks
+    DROP CONSTRAINT IF EXISTS chk_trucks_required_license_category,
+    DROP COLUMN IF EXISTS required_license_category;
+
+DROP INDEX IF EXISTS idx_drivers_license_expires_at;
+
+ALTER TABLE drivers
+    DROP CONSTRAINT IF EXISTS chk_drivers_license_period,
+    DROP CONSTRAINT IF EXISTS chk_drivers_license_category,
+    DROP COLUMN IF EXISTS license_expires_at,
+    DROP COLUMN IF EXISTS license_issued_at,
+    DROP COLUMN IF EXISTS license_state,
+    DROP COLUMN IF EXISTS license_category;
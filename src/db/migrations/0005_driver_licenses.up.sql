@@ -0,0 +1,19 @@
+-- License details of drivers and the category each truck requires. They are
+-- nullable, drivers registered before have to be completed.
+
+ALTER TABLE drivers
+    ADD COLUMN license_category text,
+    ADD COLUMN license_state text,
+    ADD COLUMN license_issued_at timestamptz,
+    ADD COLUMN license_expires_at timestamptz,
+    ADD CONSTRAINT chk_drivers_license_category
+        CHECK (license_category IN ('', 'A', 'B', 'BE', 'C', 'CE', 'D', 'DE')),
+    ADD CONSTRAINT chk_drivers_license_period
+        CHECK (license_expires_at > license_issued_at);
+
+CREATE INDEX idx_drivers_license_expires_at ON drivers (license_expires_at);
+
+ALTER TABLE trucks
+    ADD COLUMN required_license_category text,
+    ADD CONSTRAINT chk_trucks_required_license_category
+        CHECK (required_license_category IN ('', 'A', 'B', 'BE', 'C', 'CE', 'D', 'DE'));
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS maintenance_events;
+DROP TABLE IF EXISTS maintenance_plans;
+
+ALTER TABLE trucks
+    DROP CONSTRAINT IF EXISTS chk_trucks_engine_hours,
+    DROP COLUMN IF EXISTS engine_hours;
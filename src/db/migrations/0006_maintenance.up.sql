@@ -0,0 +1,58 @@
+-- Maintenance plans with their intervals, the services done on trucks and
+-- the engine hours they're counted against.
+
+ALTER TABLE trucks
+    ADD COLUMN engine_hours numeric NOT NULL DEFAULT 0,
+    ADD CONSTRAINT chk_trucks_engine_hours CHECK (engine_hours >= 0);
+
+CREATE TABLE maintenance_plans (
+    id serial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    version integer NOT NULL DEFAULT 1,
+    truck_id integer NOT NULL,
+    name text NOT NULL,
+    interval_distance numeric NOT NULL DEFAULT 0,
+    interval_engine_hours numeric NOT NULL DEFAULT 0,
+    interval_days integer NOT NULL DEFAULT 0,
+    last_service_at timestamptz NOT NULL,
+    last_service_distance numeric NOT NULL DEFAULT 0,
+    last_service_engine_hours numeric NOT NULL DEFAULT 0,
+    CONSTRAINT fk_maintenance_plans_truck FOREIGN KEY (truck_id) REFERENCES trucks (id),
+    CONSTRAINT chk_maintenance_plans_intervals CHECK (
+        interval_distance >= 0 AND interval_engine_hours >= 0 AND interval_days >= 0
+        AND (interval_distance > 0 OR interval_engine_hours > 0 OR interval_days > 0)
+    )
+);
+
+CREATE INDEX idx_maintenance_plans_deleted_at ON maintenance_plans (deleted_at);
+CREATE INDEX idx_maintenance_plans_truck_id ON maintenance_plans (truck_id);
+
+CREATE TRIGGER trg_maintenance_plans_version BEFORE UPDATE ON maintenance_plans
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+CREATE TABLE maintenance_events (
+    id serial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    version integer NOT NULL DEFAULT 1,
+    truck_id integer NOT NULL,
+    plan_id integer,
+    performed_at timestamptz NOT NULL,
+    distance_traveled numeric NOT NULL DEFAULT 0,
+    engine_hours numeric NOT NULL DEFAULT 0,
+    description text,
+    CONSTRAINT fk_maintenance_events_truck FOREIGN KEY (truck_id) REFERENCES trucks (id),
+    CONSTRAINT fk_maintenance_events_plan FOREIGN KEY (plan_id) REFERENCES maintenance_plans (id),
+    CONSTRAINT chk_maintenance_events_readings CHECK (distance_traveled >= 0 AND engine_hours >= 0)
+);
+
+CREATE INDEX idx_maintenance_events_deleted_at ON maintenance_events (deleted_at);
+CREATE INDEX idx_maintenance_events_truck_id ON maintenance_events (truck_id);
+CREATE INDEX idx_maintenance_events_plan_id ON maintenance_events (plan_id);
+CREATE INDEX idx_maintenance_events_performed_at ON maintenance_events (performed_at);
+
+CREATE TRIGGER trg_maintenance_events_version BEFORE UPDATE ON maintenance_events
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
@@ -0,0 +1,21 @@
+DROP INDEX IF EXISTS idx_trucks_make_model;
+DROP INDEX IF EXISTS idx_trucks_vehicle_model_id;
+
+ALTER TABLE trucks
+    DROP CONSTRAINT IF EXISTS chk_trucks_fuel_type,
+    DROP CONSTRAINT IF EXISTS chk_trucks_weights,
+    DROP CONSTRAINT IF EXISTS chk_trucks_axle_count,
+    DROP CONSTRAINT IF EXISTS chk_trucks_year,
+    DROP CONSTRAINT IF EXISTS fk_trucks_vehicle_model,
+    DROP CONSTRAINT IF EXISTS uni_trucks_vin,
+    DROP COLUMN IF EXISTS vehicle_model_id,
+    DROP COLUMN IF EXISTS tank_capacity,
+    DROP COLUMN IF EXISTS fuel_type,
+    DROP COLUMN IF EXISTS gross_vehicle_weight,
+    DROP COLUMN IF EXISTS axle_count,
+    DROP COLUMN IF EXISTS year,
+    DROP COLUMN IF EXISTS model,
+    DROP COLUMN IF EXISTS make,
+    DROP COLUMN IF EXISTS vin;
+
+DROP TABLE IF EXISTS vehicle_models;
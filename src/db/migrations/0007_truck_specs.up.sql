@@ -0,0 +1,51 @@
+-- Specs of trucks and the catalog of vehicle models they can be copied from.
+
+CREATE TABLE vehicle_models (
+    id serial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    version integer NOT NULL DEFAULT 1,
+    make text NOT NULL,
+    model text NOT NULL,
+    year integer,
+    axle_count integer,
+    gross_vehicle_weight numeric NOT NULL DEFAULT 0,
+    fuel_type text,
+    tank_capacity numeric NOT NULL DEFAULT 0,
+    required_license_category text,
+    CONSTRAINT chk_vehicle_models_year CHECK (year = 0 OR year BETWEEN 1900 AND 2100),
+    CONSTRAINT chk_vehicle_models_axle_count CHECK (axle_count = 0 OR axle_count BETWEEN 2 AND 10),
+    CONSTRAINT chk_vehicle_models_weights CHECK (gross_vehicle_weight >= 0 AND tank_capacity >= 0),
+    CONSTRAINT chk_vehicle_models_fuel_type
+        CHECK (fuel_type IN ('', 'diesel', 'gasoline', 'cng', 'lng', 'electric', 'hybrid')),
+    CONSTRAINT chk_vehicle_models_required_license_category
+        CHECK (required_license_category IN ('', 'A', 'B', 'BE', 'C', 'CE', 'D', 'DE'))
+);
+
+CREATE INDEX idx_vehicle_models_deleted_at ON vehicle_models (deleted_at);
+CREATE UNIQUE INDEX idx_vehicle_models_make_model_year ON vehicle_models (make, model, year);
+
+CREATE TRIGGER trg_vehicle_models_version BEFORE UPDATE ON vehicle_models
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+ALTER TABLE trucks
+    ADD COLUMN vin text,
+    ADD COLUMN make text,
+    ADD COLUMN model text,
+    ADD COLUMN year integer,
+    ADD COLUMN axle_count integer,
+    ADD COLUMN gross_vehicle_weight numeric NOT NULL DEFAULT 0,
+    ADD COLUMN fuel_type text,
+    ADD COLUMN tank_capacity numeric NOT NULL DEFAULT 0,
+    ADD COLUMN vehicle_model_id integer,
+    ADD CONSTRAINT uni_trucks_vin UNIQUE (vin),
+    ADD CONSTRAINT fk_trucks_vehicle_model FOREIGN KEY (vehicle_model_id) REFERENCES vehicle_models (id),
+    ADD CONSTRAINT chk_trucks_year CHECK (year = 0 OR year BETWEEN 1900 AND 2100),
+    ADD CONSTRAINT chk_trucks_axle_count CHECK (axle_count = 0 OR axle_count BETWEEN 2 AND 10),
+    ADD CONSTRAINT chk_trucks_weights CHECK (gross_vehicle_weight >= 0 AND tank_capacity >= 0),
+    ADD CONSTRAINT chk_trucks_fuel_type
+        CHECK (fuel_type IN ('', 'diesel', 'gasoline', 'cng', 'lng', 'electric', 'hybrid'));
+
+CREATE INDEX idx_trucks_vehicle_model_id ON trucks (vehicle_model_id);
+CREATE INDEX idx_trucks_make_model ON trucks (make, model);
@@ -0,0 +1,31 @@
+-- Odometer readings of trucks. Their distance traveled becomes the sum of the
+-- distance between readings, trucks keep the total they had as the series
+-- starts from their next reading.
+
+CREATE TABLE odometer_readings (
+    id serial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    version integer NOT NULL DEFAULT 1,
+    truck_id integer NOT NULL,
+    trip_id integer,
+    value numeric NOT NULL,
+    source text NOT NULL,
+    read_at timestamptz NOT NULL,
+    meter_replacement boolean NOT NULL DEFAULT false,
+    distance numeric NOT NULL DEFAULT 0,
+    recorded_by text,
+    CONSTRAINT fk_odometer_readings_truck FOREIGN KEY (truck_id) REFERENCES trucks (id),
+    CONSTRAINT fk_odometer_readings_trip FOREIGN KEY (trip_id) REFERENCES trips (id),
+    CONSTRAINT chk_odometer_readings_value CHECK (value >= 0 AND distance >= 0),
+    CONSTRAINT chk_odometer_readings_source CHECK (source IN ('manual', 'telematics', 'trip_close'))
+);
+
+CREATE INDEX idx_odometer_readings_deleted_at ON odometer_readings (deleted_at);
+CREATE INDEX idx_odometer_readings_truck_id ON odometer_readings (truck_id);
+CREATE INDEX idx_odometer_readings_trip_id ON odometer_readings (trip_id);
+CREATE INDEX idx_odometer_readings_read_at ON odometer_readings (read_at);
+
+CREATE TRIGGER trg_odometer_readings_version BEFORE UPDATE ON odometer_readings
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
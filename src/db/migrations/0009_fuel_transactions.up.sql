@@ -0,0 +1,33 @@
+-- Fuel purchases imported from the fuel card statements.
+
+CREATE TABLE fuel_transactions (
+    id serial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    version integer NOT NULL DEFAULT 1,
+    truck_id integer NOT NULL,
+    driver_id integer,
+    liters numeric NOT NULL,
+    unit_price numeric NOT NULL DEFAULT 0,
+    total numeric NOT NULL DEFAULT 0,
+    station text,
+    card_number text,
+    transacted_at timestamptz NOT NULL,
+    odometer numeric NOT NULL DEFAULT 0,
+    imported_by text,
+    fingerprint text NOT NULL,
+    CONSTRAINT uni_fuel_transactions_fingerprint UNIQUE (fingerprint),
+    CONSTRAINT fk_fuel_transactions_truck FOREIGN KEY (truck_id) REFERENCES trucks (id),
+    CONSTRAINT fk_fuel_transactions_driver FOREIGN KEY (driver_id) REFERENCES drivers (id),
+    CONSTRAINT chk_fuel_transactions_amounts
+        CHECK (liters > 0 AND unit_price >= 0 AND total >= 0 AND odometer >= 0)
+);
+
+CREATE INDEX idx_fuel_transactions_deleted_at ON fuel_transactions (deleted_at);
+CREATE INDEX idx_fuel_transactions_truck_id ON fuel_transactions (truck_id);
+CREATE INDEX idx_fuel_transactions_driver_id ON fuel_transactions (driver_id);
+CREATE INDEX idx_fuel_transactions_transacted_at ON fuel_transactions (transacted_at);
+
+CREATE TRIGGER trg_fuel_transactions_version BEFORE UPDATE ON fuel_transactions
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
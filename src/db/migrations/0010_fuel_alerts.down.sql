@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS fuel_alerts;
+
+ALTER TABLE fuel_transactions
+    DROP COLUMN IF EXISTS km_per_liter,
+    DROP COLUMN IF EXISTS distance;
+
+ALTER TABLE fuel_transactions RENAME COLUMN recorded_by TO imported_by;
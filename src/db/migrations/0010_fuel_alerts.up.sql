@@ -0,0 +1,40 @@
+-- Fills can be recorded by hand too, and are compared with the previous ones
+-- of the truck to raise alerts.
+
+ALTER TABLE fuel_transactions RENAME COLUMN imported_by TO recorded_by;
+
+ALTER TABLE fuel_transactions
+    ADD COLUMN distance numeric,
+    ADD COLUMN km_per_liter numeric;
+
+CREATE TABLE fuel_alerts (
+    id serial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    version integer NOT NULL DEFAULT 1,
+    truck_id integer NOT NULL,
+    transaction_id integer NOT NULL,
+    related_transaction_id integer,
+    kind text NOT NULL,
+    details text,
+    status text NOT NULL DEFAULT 'open',
+    resolved_by text,
+    resolved_at timestamptz,
+    note text,
+    CONSTRAINT fk_fuel_alerts_truck FOREIGN KEY (truck_id) REFERENCES trucks (id),
+    CONSTRAINT fk_fuel_alerts_transaction FOREIGN KEY (transaction_id) REFERENCES fuel_transactions (id),
+    CONSTRAINT fk_fuel_alerts_related_transaction FOREIGN KEY (related_transaction_id) REFERENCES fuel_transactions (id),
+    CONSTRAINT chk_fuel_alerts_kind
+        CHECK (kind IN ('over_capacity', 'high_consumption', 'no_distance', 'station_hop')),
+    CONSTRAINT chk_fuel_alerts_status CHECK (status IN ('open', 'acknowledged', 'dismissed'))
+);
+
+CREATE INDEX idx_fuel_alerts_deleted_at ON fuel_alerts (deleted_at);
+CREATE INDEX idx_fuel_alerts_truck_id ON fuel_alerts (truck_id);
+CREATE INDEX idx_fuel_alerts_transaction_id ON fuel_alerts (transaction_id);
+CREATE INDEX idx_fuel_alerts_kind ON fuel_alerts (kind);
+CREATE INDEX idx_fuel_alerts_status ON fuel_alerts (status);
+
+CREATE TRIGGER trg_fuel_alerts_version BEFORE UPDATE ON fuel_alerts
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
@@ -0,0 +1,11 @@
+-- Fails when a deleted record shares its key with another one.
+
+DROP INDEX IF EXISTS idx_drivers_license_number;
+DROP INDEX IF EXISTS idx_trucks_vin;
+DROP INDEX IF EXISTS idx_trucks_license_plate;
+
+ALTER TABLE drivers ADD CONSTRAINT uni_drivers_license_number UNIQUE (license_number);
+
+ALTER TABLE trucks
+    ADD CONSTRAINT uni_trucks_license_plate UNIQUE (license_plate),
+    ADD CONSTRAINT uni_trucks_vin UNIQUE (vin);
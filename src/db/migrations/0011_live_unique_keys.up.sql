@@ -0,0 +1,12 @@
+-- License plates, VINs and license numbers only have to be unique among the
+-- records not deleted, so the ones of a deleted record can be used again.
+
+ALTER TABLE trucks
+    DROP CONSTRAINT uni_trucks_license_plate,
+    DROP CONSTRAINT uni_trucks_vin;
+
+ALTER TABLE drivers DROP CONSTRAINT uni_drivers_license_number;
+
+CREATE UNIQUE INDEX idx_trucks_license_plate ON trucks (license_plate) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_trucks_vin ON trucks (vin) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_drivers_license_number ON drivers (license_number) WHERE deleted_at IS NULL;
@@ -0,0 +1,305 @@
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Table keeps one row per applied migration.
+const Table = "schema_migrations"
+
+//go:embed *.sql
+var files embed.FS
+
+var (
+	fileName      = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+func (appliedMigration) TableName() string {
+	return Table
+}
+
+// All returns the migrations embedded in the binary, oldest first.
+func All() ([]Migration, error) {
+	return Load(files)
+}
+
+// Load reads NNNN_name.up.sql / NNNN_name.down.sql pairs from fsys. Every
+// version needs both files, so any migration can be rolled back.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		parts := fileName.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version: %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != parts[2] {
+			return nil, fmt.Errorf("migration %d has files with different names", version)
+		}
+
+		if parts[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}
+}
+
+// Up applies every pending migration in order, each one in its own
+// transaction, and returns the ones applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, migration := range pending {
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return err
+			}
+
+			return tx.Create(&appliedMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return pending, nil
+}
+
+// Down rolls back the last steps applied migrations, newest first.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	known := m.byVersion()
+	rolledBack := []Migration{}
+
+	for i := len(applied) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		migration, ok := known[applied[i].Version]
+		if !ok {
+			return rolledBack, fmt.Errorf("migration %d_%s is applied but unknown to this binary", applied[i].Version, applied[i].Name)
+		}
+
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return err
+			}
+
+			return tx.Delete(&appliedMigration{}, "version = ?", migration.Version).Error
+		})
+
+		if err != nil {
+			return rolledBack, fmt.Errorf("rollback of %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+
+		rolledBack = append(rolledBack, migration)
+	}
+
+	return rolledBack, nil
+}
+
+// Status lists every known migration and when it was applied, if it was.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAt := map[int64]time.Time{}
+	for _, migration := range applied {
+		appliedAt[migration.Version] = migration.AppliedAt
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if at, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the known migrations that weren't applied yet.
+func (m *Migrator) Pending() ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []Migration{}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.Migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Create writes an up/down pair for the next version into dir and returns
+// their paths.
+func Create(dir string, name string, migrations []Migration) (string, string, error) {
+	if !migrationName.MatchString(name) {
+		return "", "", fmt.Errorf("migration name must be snake_case: %s", name)
+	}
+
+	var version int64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", version, name))
+	up := base + ".up.sql"
+	down := base + ".down.sql"
+
+	for path, direction := range map[string]string{up: "up", down: "down"} {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return "", "", err
+		}
+
+		_, err = fmt.Fprintf(file, "-- %s %s\n", name, direction)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return up, down, nil
+}
+
+// applied reads the applied migrations, a database that never ran any has
+// no table yet, which is only created by Up.
+func (m *Migrator) applied() ([]appliedMigration, error) {
+	applied := []appliedMigration{}
+
+	if !m.db.Migrator().HasTable(Table) {
+		return applied, nil
+	}
+
+	if err := m.db.Order("version").Find(&applied).Error; err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) ensureTable() error {
+	return m.db.Exec(`CREATE TABLE IF NOT EXISTS ` + Table + ` (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`).Error
+}
+
+func (m *Migrator) byVersion() map[int64]Migration {
+	byVersion := map[int64]Migration{}
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	return byVersion
+}
@@ -0,0 +1,173 @@
+package migrations
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func startDbMock(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	sqldb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { sqldb.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqldb}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db, mock
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name string
+
+		files fstest.MapFS
+
+		expectedVersions []int64
+		expectedErr      error
+	}{
+		{
+			name: "[Success] - Test Load Ordered By Version",
+			files: fstest.MapFS{
+				"0010_later.up.sql":   {Data: []byte("up 10")},
+				"0010_later.down.sql": {Data: []byte("down 10")},
+				"0002_first.up.sql":   {Data: []byte("up 2")},
+				"0002_first.down.sql": {Data: []byte("down 2")},
+				"README.md":           {Data: []byte("ignored")},
+			},
+			expectedVersions: []int64{2, 10},
+		},
+		{
+			name: "[Invalid] - Test Load Without Down File",
+			files: fstest.MapFS{
+				"0001_init.up.sql": {Data: []byte("up")},
+			},
+			expectedErr: errors.New("migration 1_init needs both an up and a down file"),
+		},
+		{
+			name: "[Invalid] - Test Load With Invalid Name",
+			files: fstest.MapFS{
+				"init.up.sql": {Data: []byte("up")},
+			},
+			expectedErr: errors.New("invalid migration file name: init.up.sql"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.files)
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+
+			versions := []int64{}
+			for _, migration := range migrations {
+				versions = append(versions, migration.Version)
+			}
+
+			assert.Equal(t, tt.expectedVersions, versions)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := All()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+	assert.Equal(t, int64(1), migrations[0].Version)
+}
+
+func TestMigratorUp(t *testing.T) {
+	db, mock := startDbMock(t)
+
+	migrator := NewMigrator(db, []Migration{
+		{Version: 1, Name: "init", Up: "CREATE TABLE a ()", Down: "DROP TABLE a"},
+		{Version: 2, Name: "more", Up: "CREATE TABLE b ()", Down: "DROP TABLE b"},
+	})
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT (.+) FROM \"schema_migrations\" ORDER BY version").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).AddRow(1, "init", time.Now()))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE b \\(\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO \"schema_migrations\"").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	applied, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+	assert.Equal(t, int64(2), applied[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigratorPendingOnEmptyDatabase(t *testing.T) {
+	db, mock := startDbMock(t)
+
+	migrator := NewMigrator(db, []Migration{
+		{Version: 1, Name: "init", Up: "CREATE TABLE a ()", Down: "DROP TABLE a"},
+	})
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	pending, err := migrator.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Totals are summed in place, e.g. fuel_used + $1, which only works on
+// numeric columns: a decimal left untyped would be mapped to text.
+func TestDecimalColumnsAreNumeric(t *testing.T) {
+	stored := []any{
+		&entities.Truck{},
+		&entities.Trip{},
+		&entities.VehicleModel{},
+		&entities.MaintenancePlan{},
+		&entities.MaintenanceEvent{},
+		&entities.OdometerReading{},
+		&entities.FuelTransaction{},
+	}
+
+	decimalType := reflect.TypeOf(decimal.Decimal{})
+
+	for _, model := range stored {
+		s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+		assert.NoError(t, err)
+
+		for _, field := range s.Fields {
+			fieldType := field.FieldType
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+
+			if fieldType != decimalType || field.DBName == "" {
+				continue
+			}
+
+			assert.Equal(t, schema.DataType("numeric"), field.DataType, "%s.%s", s.Table, field.DBName)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package entities
+
+import "time"
+
+type ApiKey struct {
+	GormModel
+
+	Name       string     `json:"name" validate:"required"`
+	Prefix     string     `json:"prefix" gorm:"uniqueIndex;not null"`
+	SecretHash string     `json:"-" gorm:"not null"`
+	Scopes     StringList `json:"scopes" validate:"required,min=1" gorm:"type:text;not null"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
@@ -1,9 +1,42 @@
 package entities
 
+import "time"
+
+type DriverStatus string
+
+const (
+	DriverOnboarding DriverStatus = "onboarding"
+	DriverActive     DriverStatus = "active"
+	DriverSuspended  DriverStatus = "suspended"
+	DriverOnLeave    DriverStatus = "on_leave"
+	DriverTerminated DriverStatus = "terminated"
+)
+
+// LicenseCategory is the class of vehicles a license allows, the ones ending
+// in E also cover a trailer.
+type LicenseCategory string
+
+const (
+	LicenseA  LicenseCategory = "A"
+	LicenseB  LicenseCategory = "B"
+	LicenseBE LicenseCategory = "BE"
+	LicenseC  LicenseCategory = "C"
+	LicenseCE LicenseCategory = "CE"
+	LicenseD  LicenseCategory = "D"
+	LicenseDE LicenseCategory = "DE"
+)
+
 type Driver struct {
 	GormModel
 
 	Name          string `json:"name" validate:"required"`
-	LicenseNumber string `json:"licenseNumber" validate:"required" gorm:"unique"`
-	IsActive      bool   `json:"isActive"`
+	LicenseNumber string `json:"licenseNumber" validate:"required" gorm:"uniqueIndex:idx_drivers_license_number,where:deleted_at IS NULL"`
+
+	LicenseCategory  LicenseCategory `json:"licenseCategory" validate:"omitempty,oneof=A B BE C CE D DE"`
+	LicenseState     string          `json:"licenseState" validate:"omitempty,len=2"`
+	LicenseIssuedAt  *time.Time      `json:"licenseIssuedAt"`
+	LicenseExpiresAt *time.Time      `json:"licenseExpiresAt" gorm:"index"`
+
+	// Status only changes through driverstatus.Change, which keeps its history
+	Status DriverStatus `json:"status" gorm:"not null;default:onboarding"`
 }
@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/repository/query"
+)
+
+const DriverStatusChangeDriver query.Preload[DriverStatusChange] = "Driver"
+
+type DriverStatusChange struct {
+	GormModel
+
+	DriverID int32   `json:"driverId" gorm:"not null;index"`
+	Driver   *Driver `json:"driver,omitempty" gorm:"foreignKey:DriverID"`
+
+	From      DriverStatus `json:"from" gorm:"not null"`
+	To        DriverStatus `json:"to" gorm:"not null"`
+	Reason    string       `json:"reason" gorm:"not null"`
+	ChangedBy string       `json:"changedBy"`
+	ChangedAt time.Time    `json:"changedAt" gorm:"not null;index"`
+}
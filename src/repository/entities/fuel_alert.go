@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/repository/query"
+)
+
+type FuelAlertKind string
+
+const (
+	// more liters than the tank holds
+	FuelAlertOverCapacity FuelAlertKind = "over_capacity"
+	// km per liter far below the recent fills of the truck
+	FuelAlertHighConsumption FuelAlertKind = "high_consumption"
+	// filled again without moving since the previous fill
+	FuelAlertNoDistance FuelAlertKind = "no_distance"
+	// filled at another station minutes apart
+	FuelAlertStationHop FuelAlertKind = "station_hop"
+)
+
+type FuelAlertStatus string
+
+const (
+	FuelAlertOpen         FuelAlertStatus = "open"
+	FuelAlertAcknowledged FuelAlertStatus = "acknowledged"
+	FuelAlertDismissed    FuelAlertStatus = "dismissed"
+)
+
+const (
+	FuelAlertTruck       query.Preload[FuelAlert] = "Truck"
+	FuelAlertTransaction query.Preload[FuelAlert] = "Transaction"
+)
+
+// FuelAlert flags a fill that looks like theft or a leak, for someone to
+// look into.
+type FuelAlert struct {
+	GormModel
+
+	TruckID int32  `json:"truckId" gorm:"not null;index"`
+	Truck   *Truck `json:"truck,omitempty" gorm:"foreignKey:TruckID"`
+
+	TransactionID int32            `json:"transactionId" gorm:"not null;index"`
+	Transaction   *FuelTransaction `json:"transaction,omitempty" gorm:"foreignKey:TransactionID"`
+	// the other fill, for the kinds comparing two
+	RelatedTransactionID *int32 `json:"relatedTransactionId"`
+
+	Kind    FuelAlertKind `json:"kind" gorm:"not null;index"`
+	Details string        `json:"details"`
+
+	Status     FuelAlertStatus `json:"status" gorm:"not null;default:open;index"`
+	ResolvedBy string          `json:"resolvedBy"`
+	ResolvedAt *time.Time      `json:"resolvedAt"`
+	Note       string          `json:"note"`
+}
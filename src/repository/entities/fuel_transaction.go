@@ -0,0 +1,48 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	FuelTransactionTruck  query.Preload[FuelTransaction] = "Truck"
+	FuelTransactionDriver query.Preload[FuelTransaction] = "Driver"
+)
+
+// FuelTransaction is a fill of a truck, recorded by hand or imported from
+// the fuel card statements.
+type FuelTransaction struct {
+	GormModel
+
+	TruckID int32  `json:"truckId" validate:"required" gorm:"not null;index"`
+	Truck   *Truck `json:"truck,omitempty" gorm:"foreignKey:TruckID"`
+
+	// whoever had the truck when it was fueled, unless the statement says
+	DriverID *int32  `json:"driverId" gorm:"index"`
+	Driver   *Driver `json:"driver,omitempty" gorm:"foreignKey:DriverID"`
+
+	Liters       decimal.Decimal `json:"liters" gorm:"type:numeric"`
+	UnitPrice    decimal.Decimal `json:"unitPrice" gorm:"type:numeric"`
+	Total        decimal.Decimal `json:"total" gorm:"type:numeric"`
+	Station      string          `json:"station"`
+	CardNumber   string          `json:"cardNumber"`
+	TransactedAt time.Time       `json:"transactedAt" validate:"required" gorm:"not null;index"`
+	// as reported at the pump, zero when unknown
+	Odometer decimal.Decimal `json:"odometer" gorm:"type:numeric"`
+
+	// km since the previous fill of the truck and what it gave per liter,
+	// nil when an odometer is missing on either
+	Distance   *decimal.Decimal `json:"distance" gorm:"type:numeric"`
+	KmPerLiter *decimal.Decimal `json:"kmPerLiter" gorm:"type:numeric"`
+
+	RecordedBy string `json:"recordedBy"`
+	// identifies the purchase across statements, so importing one twice
+	// doesn't duplicate it
+	Fingerprint string `json:"fingerprint" gorm:"unique"`
+
+	// raised when it was recorded
+	Alerts []FuelAlert `json:"alerts,omitempty" gorm:"foreignKey:TransactionID"`
+}
@@ -1,9 +1,18 @@
 package entities
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type GormModel struct {
-	ID        int32     `gorm:"primarykey" json:"id"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID        int32          `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt"`
+
+	// Version is bumped by the database on every update, clients send it
+	// back in If-Match to make sure they change what they have seen.
+	Version int32 `gorm:"not null;default:1;<-:create" json:"version"`
 }
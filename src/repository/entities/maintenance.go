@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	MaintenancePlanTruck  query.Preload[MaintenancePlan]  = "Truck"
+	MaintenanceEventTruck query.Preload[MaintenanceEvent] = "Truck"
+	MaintenanceEventPlan  query.Preload[MaintenanceEvent] = "Plan"
+)
+
+// MaintenancePlan is a service a truck needs every IntervalDistance km,
+// IntervalEngineHours or IntervalDays, whichever comes first. Intervals left
+// at zero aren't used.
+type MaintenancePlan struct {
+	GormModel
+
+	TruckID int32  `json:"truckId" validate:"required" gorm:"not null;index"`
+	Truck   *Truck `json:"truck,omitempty" gorm:"foreignKey:TruckID"`
+
+	Name                string          `json:"name" validate:"required"`
+	IntervalDistance    decimal.Decimal `json:"intervalDistance" gorm:"type:numeric"`
+	IntervalEngineHours decimal.Decimal `json:"intervalEngineHours" gorm:"type:numeric"`
+	IntervalDays        int32           `json:"intervalDays"`
+
+	// the intervals are counted from the last service, or from when the plan
+	// was created until there is one
+	LastServiceAt          time.Time       `json:"lastServiceAt" gorm:"not null"`
+	LastServiceDistance    decimal.Decimal `json:"lastServiceDistance" gorm:"type:numeric"`
+	LastServiceEngineHours decimal.Decimal `json:"lastServiceEngineHours" gorm:"type:numeric"`
+}
+
+// MaintenanceEvent is a service done on a truck, for one of its plans or not.
+type MaintenanceEvent struct {
+	GormModel
+
+	TruckID int32  `json:"truckId" validate:"required" gorm:"not null;index"`
+	Truck   *Truck `json:"truck,omitempty" gorm:"foreignKey:TruckID"`
+
+	PlanID *int32           `json:"planId" gorm:"index"`
+	Plan   *MaintenancePlan `json:"plan,omitempty" gorm:"foreignKey:PlanID"`
+
+	PerformedAt      time.Time       `json:"performedAt" validate:"required" gorm:"not null;index"`
+	DistanceTraveled decimal.Decimal `json:"distanceTraveled" gorm:"type:numeric"`
+	EngineHours      decimal.Decimal `json:"engineHours" gorm:"type:numeric"`
+	Description      string          `json:"description"`
+}
+
+// ServiceDue is when a maintenance plan falls due next. It is computed from
+// the truck and the plan, never stored.
+type ServiceDue struct {
+	PlanID int32  `json:"planId"`
+	Plan   string `json:"plan"`
+
+	DueDistance          *decimal.Decimal `json:"dueDistance,omitempty"`
+	RemainingDistance    *decimal.Decimal `json:"remainingDistance,omitempty"`
+	DueEngineHours       *decimal.Decimal `json:"dueEngineHours,omitempty"`
+	RemainingEngineHours *decimal.Decimal `json:"remainingEngineHours,omitempty"`
+	DueAt                *time.Time       `json:"dueAt,omitempty"`
+
+	// share of the closest interval already used, 1 when due
+	Progress decimal.Decimal `json:"progress"`
+	Overdue  bool            `json:"overdue"`
+}
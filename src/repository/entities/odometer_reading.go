@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/shopspring/decimal"
+)
+
+type OdometerSource string
+
+const (
+	OdometerManual     OdometerSource = "manual"
+	OdometerTelematics OdometerSource = "telematics"
+	OdometerTripClose  OdometerSource = "trip_close"
+)
+
+const OdometerReadingTrip query.Preload[OdometerReading] = "Trip"
+
+// OdometerReading is what the odometer of a truck showed at some point. The
+// distance traveled of the truck is the sum of the distance of its readings.
+type OdometerReading struct {
+	GormModel
+
+	TruckID int32  `json:"truckId" gorm:"not null;index"`
+	Truck   *Truck `json:"truck,omitempty" gorm:"foreignKey:TruckID"`
+
+	// set on the readings recorded by a trip
+	TripID *int32 `json:"tripId" gorm:"index"`
+	Trip   *Trip  `json:"trip,omitempty" gorm:"foreignKey:TripID"`
+
+	Value  decimal.Decimal `json:"value" gorm:"type:numeric"`
+	Source OdometerSource  `json:"source" gorm:"not null"`
+	ReadAt time.Time       `json:"readAt" gorm:"not null;index"`
+
+	// a new meter starts a new series, its first reading can be lower
+	MeterReplacement bool `json:"meterReplacement"`
+
+	// km added since the previous reading, zero for the first one of a meter
+	Distance   decimal.Decimal `json:"distance" gorm:"type:numeric"`
+	RecordedBy string          `json:"recordedBy"`
+}
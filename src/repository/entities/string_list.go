@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// StringList is stored as a comma separated text column and exposed as a
+// json array.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	return strings.Join(l, ","), nil
+}
+
+func (l *StringList) Scan(value any) error {
+	var raw string
+
+	switch v := value.(type) {
+	case nil:
+		*l = StringList{}
+		return nil
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("can't scan %T into StringList", value)
+	}
+
+	if raw == "" {
+		*l = StringList{}
+		return nil
+	}
+
+	*l = strings.Split(raw, ",")
+	return nil
+}
+
+func (l StringList) Contains(value string) bool {
+	for _, item := range l {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	TripTruck  query.Preload[Trip] = "Truck"
+	TripDriver query.Preload[Trip] = "Driver"
+)
+
+type Trip struct {
+	GormModel
+
+	TruckID int32  `json:"truckId" gorm:"not null;index"`
+	Truck   *Truck `json:"truck,omitempty" gorm:"foreignKey:TruckID"`
+
+	DriverID *int32  `json:"driverId" gorm:"index"`
+	Driver   *Driver `json:"driver,omitempty" gorm:"foreignKey:DriverID"`
+
+	StartOdometer decimal.Decimal `json:"startOdometer" gorm:"type:numeric"`
+	EndOdometer   decimal.Decimal `json:"endOdometer" gorm:"type:numeric"`
+	Distance      decimal.Decimal `json:"distance" gorm:"type:numeric"`
+	FuelLiters    decimal.Decimal `json:"fuelLiters" gorm:"type:numeric"`
+
+	StartedAt   time.Time `json:"startedAt" validate:"required" gorm:"not null;index"`
+	EndedAt     time.Time `json:"endedAt" validate:"required" gorm:"not null"`
+	Origin      string    `json:"origin"`
+	Destination string    `json:"destination"`
+}
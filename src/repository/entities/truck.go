@@ -1,16 +1,36 @@
 package entities
 
 import (
+	"github.com/mdelclaro/gobrax/src/repository/query"
 	"github.com/shopspring/decimal"
 )
 
+const (
+	TruckDriver       query.Preload[Truck] = "Driver"
+	TruckVehicleModel query.Preload[Truck] = "VehicleModel"
+)
+
 type Truck struct {
 	GormModel
 
-	LicensePlate     string          `json:"licensePlate" validate:"required" gorm:"unique"`
-	FuelUsed         decimal.Decimal `json:"fuelUsed"`
-	DistanceTraveled decimal.Decimal `json:"distanceTraveled"`
+	LicensePlate     string          `json:"licensePlate" validate:"required" gorm:"uniqueIndex:idx_trucks_license_plate,where:deleted_at IS NULL"`
+	FuelUsed         decimal.Decimal `json:"fuelUsed" gorm:"type:numeric"`
+	DistanceTraveled decimal.Decimal `json:"distanceTraveled" gorm:"type:numeric"`
+	EngineHours      decimal.Decimal `json:"engineHours" gorm:"type:numeric"`
+
+	VIN   *string `json:"vin" validate:"omitempty,vin" gorm:"uniqueIndex:idx_trucks_vin,where:deleted_at IS NULL"`
+	Make  string  `json:"make"`
+	Model string  `json:"model"`
+	Year  int32   `json:"year" validate:"omitempty,gte=1900,lte=2100"`
+
+	VehicleSpecs
+
+	VehicleModelID *int32        `json:"vehicleModelId" gorm:"index"`
+	VehicleModel   *VehicleModel `json:"vehicleModel,omitempty" gorm:"foreignKey:VehicleModelID"`
 
 	DriverID *int32  `json:"driverId" gorm:"unique"`
 	Driver   *Driver `json:"driver" gorm:"foreignKey:DriverID"`
+
+	// filled in when a single truck is read
+	NextServiceDue *ServiceDue `json:"nextServiceDue,omitempty" gorm:"-"`
 }
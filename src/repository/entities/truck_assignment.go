@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/repository/query"
+)
+
+const (
+	TruckAssignmentTruck  query.Preload[TruckAssignment] = "Truck"
+	TruckAssignmentDriver query.Preload[TruckAssignment] = "Driver"
+)
+
+type TruckAssignment struct {
+	GormModel
+
+	TruckID int32  `json:"truckId" gorm:"not null;index"`
+	Truck   *Truck `json:"truck,omitempty" gorm:"foreignKey:TruckID"`
+
+	DriverID int32   `json:"driverId" gorm:"not null;index"`
+	Driver   *Driver `json:"driver,omitempty" gorm:"foreignKey:DriverID"`
+
+	StartedAt time.Time  `json:"startedAt" gorm:"not null;index"`
+	EndedAt   *time.Time `json:"endedAt" gorm:"index"`
+	Reason    string     `json:"reason"`
+	EndReason string     `json:"endReason,omitempty"`
+}
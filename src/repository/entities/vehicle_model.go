@@ -0,0 +1,41 @@
+package entities
+
+import "github.com/shopspring/decimal"
+
+type FuelType string
+
+const (
+	FuelDiesel   FuelType = "diesel"
+	FuelGasoline FuelType = "gasoline"
+	FuelCNG      FuelType = "cng"
+	FuelLNG      FuelType = "lng"
+	FuelElectric FuelType = "electric"
+	FuelHybrid   FuelType = "hybrid"
+)
+
+// VehicleModel is a catalog entry with the specs shared by every truck of a
+// make, model and year. Trucks referencing it get the specs they leave empty
+// copied from it.
+type VehicleModel struct {
+	GormModel
+
+	Make  string `json:"make" validate:"required" gorm:"not null;uniqueIndex:idx_vehicle_models_make_model_year"`
+	Model string `json:"model" validate:"required" gorm:"not null;uniqueIndex:idx_vehicle_models_make_model_year"`
+	Year  int32  `json:"year" validate:"omitempty,gte=1900,lte=2100" gorm:"uniqueIndex:idx_vehicle_models_make_model_year"`
+
+	VehicleSpecs
+}
+
+// VehicleSpecs are the technical details of a vehicle, kept by both the
+// catalog and each truck.
+type VehicleSpecs struct {
+	AxleCount int32 `json:"axleCount" validate:"omitempty,gte=2,lte=10"`
+	// in kg
+	GrossVehicleWeight decimal.Decimal `json:"grossVehicleWeight" gorm:"type:numeric"`
+	FuelType           FuelType        `json:"fuelType" validate:"omitempty,oneof=diesel gasoline cng lng electric hybrid"`
+	// in liters
+	TankCapacity decimal.Decimal `json:"tankCapacity" gorm:"type:numeric"`
+
+	// drivers need a license covering it, any license does when empty
+	RequiredLicenseCategory LicenseCategory `json:"requiredLicenseCategory" validate:"omitempty,oneof=A B BE C CE D DE"`
+}
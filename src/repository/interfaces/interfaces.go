@@ -1,14 +1,25 @@
 package interfaces
 
-import "gorm.io/gorm"
+import (
+	"context"
+
+	"github.com/mdelclaro/gobrax/src/repository/query"
+)
 
 type IRepository interface {
-	Create(target any) error
-	FindById(target any, id int32, preloads ...string) error
-	FindAll(target any, preloads ...string) error
-	Update(target any) error
-	UpdateColumn(target any, id int32, column string, value any) error
-	Delete(target any, id int32) error
-	HandleError(res *gorm.DB) error
-	DBWithPreloads(preloads []string) *gorm.DB
+	Create(ctx context.Context, target any) error
+	FindById(ctx context.Context, target any, id int32, preloads ...string) error
+	FindAll(ctx context.Context, target any, opts query.Options) (int64, error)
+	FindFirst(ctx context.Context, target any, opts query.Options) error
+	// the ForUpdate variants lock the row they read until the transaction
+	// ends, so they can only be used inside WithTransaction
+	FindByIdForUpdate(ctx context.Context, target any, id int32) error
+	FindFirstForUpdate(ctx context.Context, target any, opts query.Options) error
+	Update(ctx context.Context, target any) error
+	UpdateColumn(ctx context.Context, target any, id int32, column string, value any) error
+	UpdateColumns(ctx context.Context, target any, id int32, values map[string]any) error
+	Increment(ctx context.Context, target any, id int32, column string, amount any) error
+	Delete(ctx context.Context, target any, id int32) error
+	Restore(ctx context.Context, target any, id int32) error
+	WithTransaction(ctx context.Context, fn func(repo IRepository) error) error
 }
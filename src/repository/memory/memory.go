@@ -0,0 +1,1019 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var errNoTransaction = apperr.New(apperr.Internal, "row locks only last for a transaction, use WithTransaction")
+
+// Repository keeps records in memory, so handlers and services can be unit
+// tested without a database. Models are read with the same gorm schema as the
+// sql repository, so columns, json names and unique constraints match.
+//
+// Transactions are serialized and rolled back by restoring a snapshot, they
+// aren't isolated from writes made outside of them.
+type Repository struct {
+	store *store
+	inTx  bool
+}
+
+type store struct {
+	mu     sync.Mutex
+	txMu   sync.Mutex
+	cache  sync.Map
+	tables map[string]*table
+}
+
+type table struct {
+	nextID int32
+	rows   map[int32]reflect.Value
+}
+
+func NewRepository() *Repository {
+	return &Repository{
+		store: &store{tables: map[string]*table{}},
+	}
+}
+
+func (r *Repository) Create(ctx context.Context, target any) error {
+	if err := ctx.Err(); err != nil {
+		return apperr.FromContext(err)
+	}
+
+	s, value, err := r.parse(target)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t := r.store.table(s)
+	now := time.Now()
+
+	for _, f := range s.Fields {
+		if f.AutoCreateTime > 0 || f.AutoUpdateTime > 0 {
+			if err := f.Set(context.Background(), value, now); err != nil {
+				return apperr.Wrap(apperr.Internal, err)
+			}
+		}
+
+		// zero fields get their default, like the columns they are stored in
+		if _, zero := f.ValueOf(context.Background(), value); zero && f.DefaultValueInterface != nil {
+			if err := f.Set(context.Background(), value, f.DefaultValueInterface); err != nil {
+				return apperr.Wrap(apperr.Internal, err)
+			}
+		}
+	}
+
+	if err := r.checkUnique(s, t, value, 0); err != nil {
+		return err
+	}
+
+	t.nextID++
+	if err := s.PrioritizedPrimaryField.Set(context.Background(), value, t.nextID); err != nil {
+		return apperr.Wrap(apperr.Internal, err)
+	}
+
+	t.rows[t.nextID] = cloneRow(s, value)
+
+	return nil
+}
+
+func (r *Repository) FindById(ctx context.Context, target any, id int32, preloads ...string) error {
+	if err := ctx.Err(); err != nil {
+		return apperr.FromContext(err)
+	}
+
+	s, value, err := r.parse(target)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	row, ok := r.store.table(s).rows[id]
+	if !ok || isDeleted(s, row) {
+		return notFound(s)
+	}
+
+	value.Set(row)
+
+	return r.preload(s, value, preloads)
+}
+
+func (r *Repository) FindAll(ctx context.Context, target any, opts query.Options) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, apperr.FromContext(err)
+	}
+
+	s, slice, err := r.parseSlice(target)
+	if err != nil {
+		return 0, err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	rows, err := r.find(s, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	total := int64(len(rows))
+	rows = paginate(rows, opts)
+
+	result := reflect.MakeSlice(slice.Type(), 0, len(rows))
+	for _, row := range rows {
+		item := reflect.New(s.ModelType).Elem()
+		item.Set(row)
+
+		if err := r.preload(s, item, opts.Preloads); err != nil {
+			return 0, err
+		}
+
+		result = reflect.Append(result, item)
+	}
+
+	slice.Set(result)
+
+	return total, nil
+}
+
+// FindByIdForUpdate only checks it runs in a transaction: transactions are
+// serialized, so no other one can change the rows it reads.
+func (r *Repository) FindByIdForUpdate(ctx context.Context, target any, id int32) error {
+	if !r.inTx {
+		return errNoTransaction
+	}
+
+	return r.FindById(ctx, target, id)
+}
+
+func (r *Repository) FindFirstForUpdate(ctx context.Context, target any, opts query.Options) error {
+	if !r.inTx {
+		return errNoTransaction
+	}
+
+	return r.FindFirst(ctx, target, opts)
+}
+
+// FindFirst loads the first record matching opts into target, leaving it
+// untouched when there is none.
+func (r *Repository) FindFirst(ctx context.Context, target any, opts query.Options) error {
+	if err := ctx.Err(); err != nil {
+		return apperr.FromContext(err)
+	}
+
+	s, value, err := r.parse(target)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	rows, err := r.find(s, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	value.Set(rows[0])
+
+	return r.preload(s, value, opts.Preloads)
+}
+
+// Update writes the non zero fields of target, like gorm Updates with a
+// struct, and loads the stored record back into it.
+func (r *Repository) Update(ctx context.Context, target any) error {
+	if err := ctx.Err(); err != nil {
+		return apperr.FromContext(err)
+	}
+
+	s, value, err := r.parse(target)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t := r.store.table(s)
+	id := idOf(s, value)
+
+	row, ok := t.rows[id]
+	if !ok || isDeleted(s, row) {
+		return notFound(s)
+	}
+
+	updated := cloneRow(s, row)
+	for _, f := range s.Fields {
+		if f.DBName == "" || f.PrimaryKey || f.AutoCreateTime > 0 || !f.Updatable {
+			continue
+		}
+
+		fieldValue, zero := f.ValueOf(context.Background(), value)
+		if zero && f.AutoUpdateTime == 0 {
+			continue
+		}
+
+		if f.AutoUpdateTime > 0 {
+			fieldValue = time.Now()
+		}
+
+		if err := f.Set(context.Background(), updated, fieldValue); err != nil {
+			return apperr.Wrap(apperr.Internal, err)
+		}
+	}
+
+	if err := bumpVersion(s, updated); err != nil {
+		return err
+	}
+
+	if err := r.checkUnique(s, t, updated, id); err != nil {
+		return err
+	}
+
+	t.rows[id] = updated
+	value.Set(cloneRow(s, updated))
+
+	return nil
+}
+
+func (r *Repository) UpdateColumn(ctx context.Context, target any, id int32, column string, value any) error {
+	return r.UpdateColumns(ctx, target, id, map[string]any{column: value})
+}
+
+func (r *Repository) UpdateColumns(ctx context.Context, target any, id int32, values map[string]any) error {
+	if err := ctx.Err(); err != nil {
+		return apperr.FromContext(err)
+	}
+
+	return r.updateColumns(target, id, values, func(column string, _ any) (any, error) {
+		return values[column], nil
+	})
+}
+
+func (r *Repository) Increment(ctx context.Context, target any, id int32, column string, amount any) error {
+	if err := ctx.Err(); err != nil {
+		return apperr.FromContext(err)
+	}
+
+	return r.updateColumns(target, id, map[string]any{column: amount}, func(_ string, current any) (any, error) {
+		return add(current, amount)
+	})
+}
+
+func (r *Repository) Delete(ctx context.Context, target any, id int32) error {
+	if err := ctx.Err(); err != nil {
+		return apperr.FromContext(err)
+	}
+
+	s, _, err := r.parse(target)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t := r.store.table(s)
+
+	row, ok := t.rows[id]
+	if !ok || isDeleted(s, row) {
+		return notFound(s)
+	}
+
+	deletedAt := s.LookUpField("DeletedAt")
+	if deletedAt == nil {
+		delete(t.rows, id)
+		return nil
+	}
+
+	row = cloneRow(s, row)
+	if err := deletedAt.Set(context.Background(), row, gorm.DeletedAt{Time: time.Now(), Valid: true}); err != nil {
+		return apperr.Wrap(apperr.Internal, err)
+	}
+
+	if err := bumpVersion(s, row); err != nil {
+		return err
+	}
+
+	t.rows[id] = row
+
+	return nil
+}
+
+// Restore brings back a soft deleted record.
+func (r *Repository) Restore(ctx context.Context, target any, id int32) error {
+	if err := ctx.Err(); err != nil {
+		return apperr.FromContext(err)
+	}
+
+	s, _, err := r.parse(target)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t := r.store.table(s)
+
+	row, ok := t.rows[id]
+	if !ok || !isDeleted(s, row) {
+		return notFound(s)
+	}
+
+	row = cloneRow(s, row)
+	if err := s.LookUpField("DeletedAt").Set(context.Background(), row, gorm.DeletedAt{}); err != nil {
+		return apperr.Wrap(apperr.Internal, err)
+	}
+
+	if err := bumpVersion(s, row); err != nil {
+		return err
+	}
+
+	// its keys may have been taken while it was deleted
+	if err := r.checkUnique(s, t, row, id); err != nil {
+		return err
+	}
+
+	t.rows[id] = row
+
+	return nil
+}
+
+func (r *Repository) WithTransaction(ctx context.Context, fn func(repo interfaces.IRepository) error) error {
+	if err := ctx.Err(); err != nil {
+		return apperr.FromContext(err)
+	}
+
+	if r.inTx {
+		return fn(r)
+	}
+
+	r.store.txMu.Lock()
+	defer r.store.txMu.Unlock()
+
+	snapshot := r.store.snapshot()
+
+	if err := fn(&Repository{store: r.store, inTx: true}); err != nil {
+		r.store.restore(snapshot)
+		return err
+	}
+
+	return nil
+}
+
+// updateColumns sets every column in columns to what next returns for it,
+// given its current value.
+func (r *Repository) updateColumns(target any, id int32, columns map[string]any, next func(column string, current any) (any, error)) error {
+	s, _, err := r.parse(target)
+	if err != nil {
+		return err
+	}
+
+	fields := make(map[string]*schema.Field, len(columns))
+	for column := range columns {
+		f, ok := s.FieldsByDBName[column]
+		if !ok {
+			return apperr.New(apperr.Internal, "unknown column %s", column)
+		}
+
+		fields[column] = f
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t := r.store.table(s)
+
+	row, ok := t.rows[id]
+	if !ok || isDeleted(s, row) {
+		return notFound(s)
+	}
+
+	row = cloneRow(s, row)
+	for column, f := range fields {
+		current, _ := f.ValueOf(context.Background(), row)
+
+		value, err := next(column, current)
+		if err != nil {
+			return err
+		}
+
+		if err := f.Set(context.Background(), row, value); err != nil {
+			return apperr.Wrap(apperr.Internal, err)
+		}
+	}
+
+	for _, updatedAt := range s.Fields {
+		if updatedAt.AutoUpdateTime > 0 {
+			if err := updatedAt.Set(context.Background(), row, time.Now()); err != nil {
+				return apperr.Wrap(apperr.Internal, err)
+			}
+		}
+	}
+
+	if err := bumpVersion(s, row); err != nil {
+		return err
+	}
+
+	if err := r.checkUnique(s, t, row, id); err != nil {
+		return err
+	}
+
+	t.rows[id] = row
+
+	return nil
+}
+
+func (r *Repository) find(s *schema.Schema, opts query.Options) ([]reflect.Value, error) {
+	filters := make([]func(reflect.Value) (bool, error), 0, len(opts.Filters))
+	for _, filter := range opts.Filters {
+		match, err := matcher(s, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, match)
+	}
+
+	less, err := sorter(s, opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []reflect.Value{}
+
+	for _, row := range r.store.table(s).rows {
+		if !opts.IncludeDeleted && isDeleted(s, row) {
+			continue
+		}
+
+		matches := true
+		for _, match := range filters {
+			ok, err := match(row)
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return less(rows[i], rows[j])
+	})
+
+	return rows, nil
+}
+
+// preload fills the belongs to associations named in preloads, the only kind
+// the entities use.
+func (r *Repository) preload(s *schema.Schema, value reflect.Value, preloads []string) error {
+	for _, name := range preloads {
+		rel, ok := s.Relationships.Relations[name]
+		if !ok || rel.Type != schema.BelongsTo || len(rel.References) != 1 {
+			return apperr.New(apperr.Internal, "unsupported preload %s", name)
+		}
+
+		foreignKey, zero := rel.References[0].ForeignKey.ValueOf(context.Background(), value)
+
+		var related any
+		if !zero {
+			id, err := toInt32(foreignKey)
+			if err != nil {
+				return apperr.Wrap(apperr.Internal, err)
+			}
+
+			if row, ok := r.store.table(rel.FieldSchema).rows[id]; ok && !isDeleted(rel.FieldSchema, row) {
+				item := reflect.New(rel.FieldSchema.ModelType)
+				item.Elem().Set(row)
+				related = item.Interface()
+			}
+		}
+
+		if related == nil {
+			related = reflect.Zero(rel.Field.FieldType).Interface()
+		}
+
+		if err := rel.Field.Set(context.Background(), value, related); err != nil {
+			return apperr.Wrap(apperr.Internal, err)
+		}
+	}
+
+	return nil
+}
+
+// checkUnique mirrors the unique constraints of the table. They also cover
+// soft deleted rows, unless the index is limited to the rows not deleted.
+func (r *Repository) checkUnique(s *schema.Schema, t *table, value reflect.Value, id int32) error {
+	for _, unique := range uniqueFields(s) {
+		f := unique.field
+
+		fieldValue, zero := f.ValueOf(context.Background(), value)
+		if zero && f.FieldType.Kind() == reflect.Ptr {
+			continue
+		}
+
+		if unique.live && isDeleted(s, value) {
+			continue
+		}
+
+		for rowID, row := range t.rows {
+			if rowID == id || unique.live && isDeleted(s, row) {
+				continue
+			}
+
+			other, _ := f.ValueOf(context.Background(), row)
+			if reflect.DeepEqual(deref(other), deref(fieldValue)) {
+				return apperr.New(apperr.Conflict, "Key (%s)=(%v) already exists.", f.DBName, deref(fieldValue))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) parse(target any) (*schema.Schema, reflect.Value, error) {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return nil, value, apperr.New(apperr.Internal, "target must be a pointer to a struct, got %T", target)
+	}
+
+	s, err := schema.Parse(target, &r.store.cache, schema.NamingStrategy{})
+	if err != nil {
+		return nil, value, apperr.Wrap(apperr.Internal, err)
+	}
+
+	return s, value.Elem(), nil
+}
+
+func (r *Repository) parseSlice(target any) (*schema.Schema, reflect.Value, error) {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Slice {
+		return nil, value, apperr.New(apperr.Internal, "target must be a pointer to a slice, got %T", target)
+	}
+
+	s, err := schema.Parse(target, &r.store.cache, schema.NamingStrategy{})
+	if err != nil {
+		return nil, value, apperr.Wrap(apperr.Internal, err)
+	}
+
+	return s, value.Elem(), nil
+}
+
+func (s *store) table(sch *schema.Schema) *table {
+	t, ok := s.tables[sch.Table]
+	if !ok {
+		t = &table{rows: map[int32]reflect.Value{}}
+		s.tables[sch.Table] = t
+	}
+
+	return t
+}
+
+func (s *store) snapshot() map[string]table {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := map[string]table{}
+	for name, t := range s.tables {
+		rows := make(map[int32]reflect.Value, len(t.rows))
+		for id, row := range t.rows {
+			rows[id] = row
+		}
+
+		snapshot[name] = table{nextID: t.nextID, rows: rows}
+	}
+
+	return snapshot
+}
+
+func (s *store) restore(snapshot map[string]table) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tables = map[string]*table{}
+	for name, t := range snapshot {
+		t := t
+		s.tables[name] = &t
+	}
+}
+
+// matcher resolves a filter the same way the sql repository does: by the
+// json name of the field, coercing the raw query values to the field type.
+func matcher(s *schema.Schema, filter query.Filter) (func(reflect.Value) (bool, error), error) {
+	f, err := fieldFor(s, filter.Field)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Operator == query.Like {
+		pattern := strings.ToLower(fmt.Sprint(filter.Value))
+
+		return func(row reflect.Value) (bool, error) {
+			value, _ := f.ValueOf(context.Background(), row)
+			return strings.Contains(strings.ToLower(fmt.Sprint(deref(value))), pattern), nil
+		}, nil
+	}
+
+	switch filter.Operator {
+	case query.Equal, query.NotEqual, query.GreaterOrEqual, query.LessOrEqual:
+	default:
+		return nil, fmt.Errorf("%w: %s", query.ErrUnknownOperator, filter.Operator)
+	}
+
+	values, isList := filter.Value.([]int32)
+
+	return func(row reflect.Value) (bool, error) {
+		value, _ := f.ValueOf(context.Background(), row)
+		value = deref(value)
+
+		if isList {
+			for _, item := range values {
+				if cmp, err := compare(value, item); err == nil && cmp == 0 {
+					return filter.Operator == query.Equal, nil
+				}
+			}
+
+			return filter.Operator != query.Equal, nil
+		}
+
+		if filter.Value == nil {
+			return (value == nil) == (filter.Operator == query.Equal), nil
+		}
+
+		if value == nil {
+			return false, nil
+		}
+
+		cmp, err := compare(value, filter.Value)
+		if err != nil {
+			return false, apperr.New(apperr.Validation, "invalid %s provided: %s", filter.Field, err.Error())
+		}
+
+		switch filter.Operator {
+		case query.Equal:
+			return cmp == 0, nil
+		case query.NotEqual:
+			return cmp != 0, nil
+		case query.GreaterOrEqual:
+			return cmp >= 0, nil
+		default:
+			return cmp <= 0, nil
+		}
+	}, nil
+}
+
+func sorter(s *schema.Schema, sorts []query.Sort) (func(a, b reflect.Value) bool, error) {
+	fields := make([]*schema.Field, 0, len(sorts))
+	for _, sort := range sorts {
+		f, err := fieldFor(s, sort.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, f)
+	}
+
+	return func(a, b reflect.Value) bool {
+		for i, f := range fields {
+			left, _ := f.ValueOf(context.Background(), a)
+			right, _ := f.ValueOf(context.Background(), b)
+
+			cmp := compareNullable(deref(left), deref(right))
+			if cmp == 0 {
+				continue
+			}
+
+			if sorts[i].Desc {
+				return cmp > 0
+			}
+
+			return cmp < 0
+		}
+
+		// always break ties by primary key, like the sql repository
+		return idOf(s, a) < idOf(s, b)
+	}, nil
+}
+
+func fieldFor(s *schema.Schema, field string) (*schema.Field, error) {
+	for _, f := range s.Fields {
+		if f.DBName == "" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == field {
+			return f, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", query.ErrUnknownField, field)
+}
+
+type uniqueField struct {
+	field *schema.Field
+	// only unique among the rows not deleted
+	live bool
+}
+
+func uniqueFields(s *schema.Schema) []uniqueField {
+	fields := []uniqueField{}
+	for _, f := range s.Fields {
+		if f.Unique {
+			fields = append(fields, uniqueField{field: f})
+		}
+	}
+
+	for _, idx := range s.ParseIndexes() {
+		if idx.Class == "UNIQUE" && len(idx.Fields) == 1 {
+			live := strings.Contains(idx.Where, "deleted_at IS NULL")
+			fields = append(fields, uniqueField{field: idx.Fields[0].Field, live: live})
+		}
+	}
+
+	return fields
+}
+
+func paginate(rows []reflect.Value, opts query.Options) []reflect.Value {
+	if opts.Offset >= len(rows) {
+		return []reflect.Value{}
+	}
+
+	rows = rows[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(rows) {
+		rows = rows[:opts.Limit]
+	}
+
+	return rows
+}
+
+// cloneRow copies a record without its associations, which aren't stored.
+func cloneRow(s *schema.Schema, value reflect.Value) reflect.Value {
+	row := reflect.New(s.ModelType).Elem()
+	row.Set(value)
+
+	for _, rel := range s.Relationships.Relations {
+		// gorm also lists here the has many relations of other models
+		// pointing at this one
+		if rel.Field.Schema != s {
+			continue
+		}
+
+		field := rel.Field.ReflectValueOf(context.Background(), row)
+		field.Set(reflect.Zero(field.Type()))
+	}
+
+	return row
+}
+
+func isDeleted(s *schema.Schema, row reflect.Value) bool {
+	f := s.LookUpField("DeletedAt")
+	if f == nil {
+		return false
+	}
+
+	value, _ := f.ValueOf(context.Background(), row)
+	deletedAt, ok := value.(gorm.DeletedAt)
+
+	return ok && deletedAt.Valid
+}
+
+// bumpVersion increments the version of a row being updated, as the trigger
+// of the sql tables does.
+func bumpVersion(s *schema.Schema, row reflect.Value) error {
+	f := s.LookUpField("Version")
+	if f == nil {
+		return nil
+	}
+
+	current, _ := f.ValueOf(context.Background(), row)
+	version, err := toInt32(current)
+	if err != nil {
+		return apperr.Wrap(apperr.Internal, err)
+	}
+
+	if err := f.Set(context.Background(), row, version+1); err != nil {
+		return apperr.Wrap(apperr.Internal, err)
+	}
+
+	return nil
+}
+
+func idOf(s *schema.Schema, row reflect.Value) int32 {
+	value, _ := s.PrioritizedPrimaryField.ValueOf(context.Background(), row)
+	id, _ := toInt32(value)
+
+	return id
+}
+
+func notFound(s *schema.Schema) error {
+	var name strings.Builder
+	for i, char := range s.Name {
+		if unicode.IsUpper(char) {
+			if i != 0 {
+				name.WriteByte(' ')
+			}
+
+			char = unicode.ToLower(char)
+		}
+
+		name.WriteRune(char)
+	}
+
+	return apperr.New(apperr.NotFound, "%s not found", name.String())
+}
+
+func deref(value any) any {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return nil
+	}
+
+	return v.Interface()
+}
+
+func toInt32(value any) (int32, error) {
+	switch v := deref(value).(type) {
+	case int32:
+		return v, nil
+	case int:
+		return int32(v), nil
+	case int64:
+		return int32(v), nil
+	default:
+		return 0, fmt.Errorf("can't use %T as an id", value)
+	}
+}
+
+func compareNullable(a any, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	cmp, _ := compare(a, b)
+	return cmp
+}
+
+// compare orders value against other, converting other to the type of value
+// first, as filters arrive as strings from the query.
+func compare(value any, other any) (int, error) {
+	switch v := value.(type) {
+	case string:
+		return strings.Compare(v, fmt.Sprint(other)), nil
+	case bool:
+		o, err := strconv.ParseBool(fmt.Sprint(deref(other)))
+		if err != nil {
+			return 0, err
+		}
+
+		if v == o {
+			return 0, nil
+		}
+
+		if !v {
+			return -1, nil
+		}
+
+		return 1, nil
+	case decimal.Decimal:
+		o, err := decimal.NewFromString(fmt.Sprint(deref(other)))
+		if err != nil {
+			return 0, err
+		}
+
+		return v.Cmp(o), nil
+	case time.Time:
+		o, ok := deref(other).(time.Time)
+		if !ok {
+			parsed, err := time.Parse(time.RFC3339, fmt.Sprint(deref(other)))
+			if err != nil {
+				return 0, err
+			}
+
+			o = parsed
+		}
+
+		return v.Compare(o), nil
+	case gorm.DeletedAt:
+		if !v.Valid {
+			return compareNullable(nil, other), nil
+		}
+
+		return compare(v.Time, other)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		o, err := strconv.ParseInt(fmt.Sprint(deref(other)), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		return compareOrdered(rv.Int(), o), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		o, err := strconv.ParseUint(fmt.Sprint(deref(other)), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		return compareOrdered(rv.Uint(), o), nil
+	case reflect.Float32, reflect.Float64:
+		o, err := strconv.ParseFloat(fmt.Sprint(deref(other)), 64)
+		if err != nil {
+			return 0, err
+		}
+
+		return compareOrdered(rv.Float(), o), nil
+	}
+
+	return strings.Compare(fmt.Sprint(value), fmt.Sprint(other)), nil
+}
+
+func compareOrdered[T int64 | uint64 | float64](a T, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func add(current any, amount any) (any, error) {
+	switch v := current.(type) {
+	case decimal.Decimal:
+		a, ok := amount.(decimal.Decimal)
+		if !ok {
+			return nil, apperr.New(apperr.Internal, "can't add %T to a decimal", amount)
+		}
+
+		return v.Add(a), nil
+	case int32:
+		a, err := toInt32(amount)
+		return v + a, err
+	case int64:
+		a, ok := amount.(int64)
+		if !ok {
+			return nil, apperr.New(apperr.Internal, "can't add %T to an int64", amount)
+		}
+
+		return v + a, nil
+	case float64:
+		a, ok := amount.(float64)
+		if !ok {
+			return nil, apperr.New(apperr.Internal, "can't add %T to a float64", amount)
+		}
+
+		return v + a, nil
+	default:
+		return nil, apperr.New(apperr.Internal, "can't increment %T", current)
+	}
+}
@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var ctx = context.Background()
+
+func seed(t *testing.T, repo *Repository) (entities.Driver, entities.Truck) {
+	driver := entities.Driver{Name: "driver", LicenseNumber: "123", Status: entities.DriverActive}
+	assert.NoError(t, repo.Create(ctx, &driver))
+
+	truck := entities.Truck{LicensePlate: "ABC", DriverID: &driver.ID}
+	assert.NoError(t, repo.Create(ctx, &truck))
+
+	return driver, truck
+}
+
+func TestCreateAndFind(t *testing.T) {
+	repo := NewRepository()
+	driver, truck := seed(t, repo)
+
+	assert.Equal(t, int32(1), driver.ID)
+	assert.False(t, driver.CreatedAt.IsZero())
+
+	found := entities.Truck{}
+	assert.NoError(t, repo.FindById(ctx, &found, truck.ID, "Driver"))
+	assert.Equal(t, "ABC", found.LicensePlate)
+	assert.Equal(t, driver.Name, found.Driver.Name)
+
+	err := repo.FindById(ctx, &found, 99)
+	assert.True(t, apperr.Is(err, apperr.NotFound))
+	assert.EqualError(t, err, "truck not found")
+}
+
+func TestUniqueConstraints(t *testing.T) {
+	repo := NewRepository()
+	driver, _ := seed(t, repo)
+
+	err := repo.Create(ctx, &entities.Driver{Name: "other", LicenseNumber: "123"})
+	assert.True(t, apperr.Is(err, apperr.Conflict))
+
+	// trucks without a driver don't clash on the nullable unique column
+	assert.NoError(t, repo.Create(ctx, &entities.Truck{LicensePlate: "DEF"}))
+	assert.NoError(t, repo.Create(ctx, &entities.Truck{LicensePlate: "GHI"}))
+
+	err = repo.Create(ctx, &entities.Truck{LicensePlate: "JKL", DriverID: &driver.ID})
+	assert.True(t, apperr.Is(err, apperr.Conflict))
+
+	// a deleted driver's license number can be used again
+	assert.NoError(t, repo.Delete(ctx, &entities.Driver{}, driver.ID))
+	assert.NoError(t, repo.Create(ctx, &entities.Driver{Name: "other", LicenseNumber: "123"}))
+}
+
+func TestFindAll(t *testing.T) {
+	repo := NewRepository()
+
+	for _, name := range []string{"carol", "alice", "bob"} {
+		status := entities.DriverActive
+		if name == "bob" {
+			status = entities.DriverSuspended
+		}
+
+		assert.NoError(t, repo.Create(ctx, &entities.Driver{Name: name, LicenseNumber: name, Status: status}))
+	}
+
+	tests := []struct {
+		name string
+
+		opts query.Options
+
+		expectedNames []string
+		expectedTotal int64
+		expectedErr   error
+	}{
+		{
+			name:          "[Success] - Default Order",
+			opts:          query.New(),
+			expectedNames: []string{"carol", "alice", "bob"},
+			expectedTotal: 3,
+		},
+		{
+			name:          "[Success] - Sorted And Paginated",
+			opts:          query.Options{Limit: 2, Offset: 1, Sort: []query.Sort{{Field: "name"}}},
+			expectedNames: []string{"bob", "carol"},
+			expectedTotal: 3,
+		},
+		{
+			name:          "[Success] - Filtered By Query String",
+			opts:          query.New().Where("status", query.Equal, "active").Where("name", query.Like, "AR"),
+			expectedNames: []string{"carol"},
+			expectedTotal: 1,
+		},
+		{
+			name:        "[Error] - Unknown Field",
+			opts:        query.New().Where("secret", query.Equal, "x"),
+			expectedErr: query.ErrUnknownField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drivers := []entities.Driver{}
+
+			total, err := repo.FindAll(ctx, &drivers, tt.opts)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedTotal, total)
+
+			names := []string{}
+			for _, driver := range drivers {
+				names = append(names, driver.Name)
+			}
+
+			assert.Equal(t, tt.expectedNames, names)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	repo := NewRepository()
+	_, truck := seed(t, repo)
+
+	assert.NoError(t, repo.Increment(ctx, &entities.Truck{}, truck.ID, "fuel_used", decimal.NewFromInt(10)))
+	assert.NoError(t, repo.UpdateColumn(ctx, &entities.Truck{}, truck.ID, "driver_id", nil))
+
+	// zero fields are left as they are
+	update := entities.Truck{GormModel: entities.GormModel{ID: truck.ID}, LicensePlate: "XYZ"}
+	assert.NoError(t, repo.Update(ctx, &update))
+
+	assert.Equal(t, "XYZ", update.LicensePlate)
+	assert.Nil(t, update.DriverID)
+	assert.True(t, decimal.NewFromInt(10).Equal(update.FuelUsed))
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	repo := NewRepository()
+	driver, _ := seed(t, repo)
+
+	assert.NoError(t, repo.Delete(ctx, &entities.Driver{}, driver.ID))
+	assert.True(t, apperr.Is(repo.FindById(ctx, &entities.Driver{}, driver.ID), apperr.NotFound))
+
+	drivers := []entities.Driver{}
+	total, err := repo.FindAll(ctx, &drivers, query.Options{IncludeDeleted: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.True(t, drivers[0].DeletedAt.Valid)
+
+	assert.NoError(t, repo.Restore(ctx, &entities.Driver{}, driver.ID))
+	assert.NoError(t, repo.FindById(ctx, &entities.Driver{}, driver.ID))
+}
+
+func TestTransactionRollback(t *testing.T) {
+	repo := NewRepository()
+	errAbort := errors.New("abort")
+
+	err := repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		if err := tx.Create(ctx, &entities.Driver{Name: "driver", LicenseNumber: "123"}); err != nil {
+			return err
+		}
+
+		return errAbort
+	})
+
+	assert.ErrorIs(t, err, errAbort)
+
+	drivers := []entities.Driver{}
+	total, err := repo.FindAll(ctx, &drivers, query.New())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestFindForUpdate(t *testing.T) {
+	repo := NewRepository()
+	driver, _ := seed(t, repo)
+
+	err := repo.FindByIdForUpdate(ctx, &entities.Driver{}, driver.ID)
+	assert.True(t, apperr.Is(err, apperr.Internal))
+
+	err = repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		locked := entities.Driver{}
+		if err := tx.FindByIdForUpdate(ctx, &locked, driver.ID); err != nil {
+			return err
+		}
+
+		assert.Equal(t, driver.Name, locked.Name)
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+}
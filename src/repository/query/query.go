@@ -0,0 +1,116 @@
+package query
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+var (
+	ErrUnknownField    = apperr.New(apperr.Validation, "unknown field")
+	ErrUnknownOperator = apperr.New(apperr.Validation, "unknown operator")
+)
+
+type Operator string
+
+const (
+	Equal          Operator = "="
+	NotEqual       Operator = "!="
+	Like           Operator = "~="
+	GreaterOrEqual Operator = ">="
+	LessOrEqual    Operator = "<="
+)
+
+// Filter restricts a listing to rows whose field matches value. Field is the
+// json name of the entity field, the repository resolves it to a column.
+type Filter struct {
+	Field    string
+	Operator Operator
+	Value    any
+}
+
+// Preload names an association of T, so preloading one that belongs to
+// another model fails to compile instead of at query time.
+type Preload[T any] string
+
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+type Options struct {
+	Limit  int
+	Offset int
+	// Page is only set when the client paginates with page/pageSize,
+	// so the response meta mirrors the style that was requested.
+	Page int
+
+	Sort     []Sort
+	Filters  []Filter
+	Preloads []string
+
+	// IncludeDeleted also lists soft deleted records
+	IncludeDeleted bool
+}
+
+type Meta struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Page       int    `json:"page,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+func New(preloads ...string) Options {
+	return Options{
+		Limit:    DefaultLimit,
+		Preloads: preloads,
+	}
+}
+
+func (o Options) Where(field string, op Operator, value any) Options {
+	o.Filters = append(o.Filters, Filter{Field: field, Operator: op, Value: value})
+	return o
+}
+
+func (o Options) OrderBy(field string, desc bool) Options {
+	o.Sort = append(o.Sort, Sort{Field: field, Desc: desc})
+	return o
+}
+
+func (o Options) BuildMeta(total int64) Meta {
+	meta := Meta{
+		Total: total,
+		Limit: o.Limit,
+		Page:  o.Page,
+	}
+
+	if next := o.Offset + o.Limit; o.Limit > 0 && int64(next) < total {
+		meta.NextCursor = EncodeCursor(next)
+	}
+
+	return meta
+}
+
+func EncodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func DecodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, apperr.New(apperr.Validation, "invalid cursor")
+	}
+
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, apperr.New(apperr.Validation, "invalid cursor")
+	}
+
+	return offset, nil
+}
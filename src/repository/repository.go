@@ -1,15 +1,42 @@
 package repository
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"unicode"
 
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mdelclaro/gobrax/src/apperr"
+
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
+// postgres error codes caused by the data sent, anything else is internal
+var pgErrorKinds = map[string]apperr.Kind{
+	"23505": apperr.Conflict,   // unique_violation
+	"23503": apperr.Conflict,   // foreign_key_violation
+	"40001": apperr.Conflict,   // serialization_failure
+	"40P01": apperr.Conflict,   // deadlock_detected
+	"57014": apperr.Timeout,    // query_canceled, by statement_timeout
+	"23502": apperr.Validation, // not_null_violation
+	"23514": apperr.Validation, // check_violation
+	"22001": apperr.Validation, // string_data_right_truncation
+	"22003": apperr.Validation, // numeric_value_out_of_range
+	"22P02": apperr.Validation, // invalid_text_representation
+}
+
+var errNoTransaction = apperr.New(apperr.Internal, "row locks only last for a transaction, use WithTransaction")
+
 type Repository struct {
 	db           *gorm.DB
 	defaultJoins []string
+	inTx         bool
 }
 
 func NewRepository(db *gorm.DB, joins ...string) *Repository {
@@ -19,60 +46,249 @@ func NewRepository(db *gorm.DB, joins ...string) *Repository {
 	}
 }
 
-func (r *Repository) Create(target any) error {
-	res := r.db.Create(target)
+func (r *Repository) Create(ctx context.Context, target any) error {
+	res := r.db.WithContext(ctx).Create(target)
 	return r.HandleError(res)
 }
 
-func (r *Repository) FindById(target any, id int32, preloads ...string) error {
-	res := r.DBWithPreloads(preloads).First(target, id)
+func (r *Repository) FindById(ctx context.Context, target any, id int32, preloads ...string) error {
+	res := r.DBWithPreloads(ctx, preloads).First(target, id)
 	return r.HandleError(res)
 }
 
-func (r *Repository) FindAll(target any, preloads ...string) error {
-	res := r.DBWithPreloads(preloads).Find(target)
+// FindByIdForUpdate reads the record with SELECT ... FOR UPDATE, so other
+// transactions wait to write or lock it until this one ends.
+func (r *Repository) FindByIdForUpdate(ctx context.Context, target any, id int32) error {
+	if !r.inTx {
+		return errNoTransaction
+	}
+
+	res := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		First(target, id)
+
 	return r.HandleError(res)
 }
 
-func (r *Repository) Update(target any) error {
-	res := r.db.
+func (r *Repository) FindAll(ctx context.Context, target any, opts query.Options) (int64, error) {
+	conds, orders, err := r.parseOptions(target, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	countQuery := r.db.WithContext(ctx).Model(target)
+	if opts.IncludeDeleted {
+		countQuery = countQuery.Unscoped()
+	}
+	if len(conds) > 0 {
+		countQuery = countQuery.Clauses(clause.Where{Exprs: conds})
+	}
+
+	if err := r.HandleError(countQuery.Count(&total)); err != nil {
+		return 0, err
+	}
+
+	dbConn := r.DBWithPreloads(ctx, opts.Preloads)
+	if opts.IncludeDeleted {
+		dbConn = dbConn.Unscoped()
+	}
+
+	if len(conds) > 0 {
+		dbConn = dbConn.Clauses(clause.Where{Exprs: conds})
+	}
+
+	if len(orders) > 0 {
+		dbConn = dbConn.Clauses(clause.OrderBy{Columns: orders})
+	}
+
+	if opts.Limit > 0 {
+		dbConn = dbConn.Limit(opts.Limit)
+	}
+
+	if opts.Offset > 0 {
+		dbConn = dbConn.Offset(opts.Offset)
+	}
+
+	res := dbConn.Find(target)
+	return total, r.HandleError(res)
+}
+
+// FindFirst loads the first record matching opts into target, leaving it
+// untouched when there is none, unlike FindById which reports it as NotFound.
+func (r *Repository) FindFirst(ctx context.Context, target any, opts query.Options) error {
+	return r.findFirst(ctx, target, opts, false)
+}
+
+// FindFirstForUpdate is FindFirst locking the row found, like FindByIdForUpdate.
+func (r *Repository) FindFirstForUpdate(ctx context.Context, target any, opts query.Options) error {
+	if !r.inTx {
+		return errNoTransaction
+	}
+
+	return r.findFirst(ctx, target, opts, true)
+}
+
+func (r *Repository) findFirst(ctx context.Context, target any, opts query.Options, lock bool) error {
+	conds, orders, err := r.parseOptions(target, opts)
+	if err != nil {
+		return err
+	}
+
+	dbConn := r.DBWithPreloads(ctx, opts.Preloads)
+	if opts.IncludeDeleted {
+		dbConn = dbConn.Unscoped()
+	}
+
+	if len(conds) > 0 {
+		dbConn = dbConn.Clauses(clause.Where{Exprs: conds})
+	}
+
+	if len(orders) > 0 {
+		dbConn = dbConn.Clauses(clause.OrderBy{Columns: orders})
+	}
+
+	if lock {
+		dbConn = dbConn.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate})
+	}
+
+	res := dbConn.Limit(1).Find(target)
+	return r.HandleError(res)
+}
+
+func (r *Repository) Update(ctx context.Context, target any) error {
+	res := r.db.WithContext(ctx).
 		Model(target).
 		Clauses(clause.Returning{}).
 		Updates(target)
 
-	if res.RowsAffected == 0 {
-		res.Error = fmt.Errorf("record not found")
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = gorm.ErrRecordNotFound
 	}
 
 	return r.HandleError(res)
 }
 
-func (r *Repository) UpdateColumn(target any, id int32, column string, value any) error {
-	res := r.db.Delete(target, id)
+func (r *Repository) UpdateColumn(ctx context.Context, target any, id int32, column string, value any) error {
+	res := r.db.WithContext(ctx).
+		Model(target).
+		Where("id = ?", id).
+		Update(column, value)
+
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = gorm.ErrRecordNotFound
+	}
 
 	return r.HandleError(res)
 }
 
-func (r *Repository) Delete(target any, id int32) error {
-	res := r.db.Delete(target, id)
-	if res.RowsAffected == 0 {
-		res.Error = fmt.Errorf("record not found")
+// UpdateColumns sets several columns of a record in a single statement, zero
+// values included.
+func (r *Repository) UpdateColumns(ctx context.Context, target any, id int32, values map[string]any) error {
+	res := r.db.WithContext(ctx).
+		Model(target).
+		Where("id = ?", id).
+		Updates(values)
+
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = gorm.ErrRecordNotFound
 	}
 
 	return r.HandleError(res)
 }
 
+// Increment adds amount to column in a single statement, so concurrent
+// writers can't overwrite each other's totals.
+func (r *Repository) Increment(ctx context.Context, target any, id int32, column string, amount any) error {
+	res := r.db.WithContext(ctx).
+		Model(target).
+		Where("id = ?", id).
+		Update(column, gorm.Expr("? + ?", clause.Column{Name: column}, amount))
+
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = gorm.ErrRecordNotFound
+	}
+
+	return r.HandleError(res)
+}
+
+func (r *Repository) Delete(ctx context.Context, target any, id int32) error {
+	res := r.db.WithContext(ctx).Delete(target, id)
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = gorm.ErrRecordNotFound
+	}
+
+	return r.HandleError(res)
+}
+
+// Restore brings back a soft deleted record.
+func (r *Repository) Restore(ctx context.Context, target any, id int32) error {
+	res := r.db.WithContext(ctx).
+		Unscoped().
+		Model(target).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = gorm.ErrRecordNotFound
+	}
+
+	return r.HandleError(res)
+}
+
+// WithTransaction runs fn against a repository bound to a single database
+// transaction, which is committed when fn returns nil and rolled back otherwise.
+// Calls made while already inside a transaction join it instead of nesting.
+func (r *Repository) WithTransaction(ctx context.Context, fn func(repo interfaces.IRepository) error) error {
+	if r.inTx {
+		return fn(r)
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := NewRepository(tx, r.defaultJoins...)
+		txRepo.inTx = true
+
+		return fn(txRepo)
+	})
+}
+
+// HandleError translates database errors into apperr kinds, so callers can
+// tell a missing record or a duplicate apart from a failing database.
 func (r *Repository) HandleError(res *gorm.DB) error {
-	if res.Error != nil && res.Error != gorm.ErrRecordNotFound {
-		err := fmt.Errorf("%w", res.Error)
-		return err
+	if res.Error == nil {
+		return nil
 	}
 
-	return nil
+	var typed *apperr.Error
+	if errors.As(res.Error, &typed) {
+		return res.Error
+	}
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return apperr.New(apperr.NotFound, "%s not found", recordName(res.Statement))
+	}
+
+	if errors.Is(res.Error, context.Canceled) || errors.Is(res.Error, context.DeadlineExceeded) {
+		return apperr.FromContext(res.Error)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(res.Error, &pgErr) {
+		if kind, ok := pgErrorKinds[pgErr.Code]; ok {
+			message := pgErr.Detail
+			if message == "" {
+				message = pgErr.Message
+			}
+
+			return apperr.Wrap(kind, errors.New(message))
+		}
+	}
+
+	return apperr.Wrap(apperr.Internal, res.Error)
 }
 
-func (r *Repository) DBWithPreloads(preloads []string) *gorm.DB {
-	dbConn := r.db
+func (r *Repository) DBWithPreloads(ctx context.Context, preloads []string) *gorm.DB {
+	dbConn := r.db.WithContext(ctx)
 
 	for _, join := range r.defaultJoins {
 		dbConn = dbConn.Joins(join)
@@ -84,3 +300,120 @@ func (r *Repository) DBWithPreloads(preloads []string) *gorm.DB {
 
 	return dbConn
 }
+
+func (r *Repository) parseOptions(target any, opts query.Options) ([]clause.Expression, []clause.OrderByColumn, error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(target); err != nil {
+		return nil, nil, apperr.Wrap(apperr.Internal, err)
+	}
+
+	conds, err := buildConditions(stmt.Schema, opts.Filters)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	orders, err := buildOrders(stmt.Schema, opts.Sort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conds, orders, nil
+}
+
+// columnFor resolves the json name of a field, as exposed by the API, to its
+// table qualified column so joined tables can't make it ambiguous.
+func columnFor(s *schema.Schema, field string) (clause.Column, error) {
+	for _, f := range s.Fields {
+		if f.DBName == "" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == field {
+			return clause.Column{Table: s.Table, Name: f.DBName}, nil
+		}
+	}
+
+	return clause.Column{}, fmt.Errorf("%w: %s", query.ErrUnknownField, field)
+}
+
+func buildConditions(s *schema.Schema, filters []query.Filter) ([]clause.Expression, error) {
+	conds := []clause.Expression{}
+
+	for _, filter := range filters {
+		column, err := columnFor(s, filter.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		switch filter.Operator {
+		case query.Equal:
+			conds = append(conds, clause.Eq{Column: column, Value: filter.Value})
+		case query.NotEqual:
+			conds = append(conds, clause.Neq{Column: column, Value: filter.Value})
+		case query.GreaterOrEqual:
+			conds = append(conds, clause.Gte{Column: column, Value: filter.Value})
+		case query.LessOrEqual:
+			conds = append(conds, clause.Lte{Column: column, Value: filter.Value})
+		case query.Like:
+			conds = append(conds, clause.Expr{
+				SQL:  "CAST(? AS TEXT) ILIKE ?",
+				Vars: []any{column, fmt.Sprintf("%%%v%%", filter.Value)},
+			})
+		default:
+			return nil, fmt.Errorf("%w: %s", query.ErrUnknownOperator, filter.Operator)
+		}
+	}
+
+	return conds, nil
+}
+
+func buildOrders(s *schema.Schema, sorts []query.Sort) ([]clause.OrderByColumn, error) {
+	orders := []clause.OrderByColumn{}
+	sortedByPk := false
+
+	for _, sort := range sorts {
+		column, err := columnFor(s, sort.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.PrioritizedPrimaryField != nil && column.Name == s.PrioritizedPrimaryField.DBName {
+			sortedByPk = true
+		}
+
+		orders = append(orders, clause.OrderByColumn{Column: column, Desc: sort.Desc})
+	}
+
+	// always break ties by primary key, otherwise pages can overlap
+	if !sortedByPk && s.PrioritizedPrimaryField != nil {
+		orders = append(orders, clause.OrderByColumn{
+			Column: clause.Column{Table: s.Table, Name: s.PrioritizedPrimaryField.DBName},
+		})
+	}
+
+	return orders, nil
+}
+
+// recordName turns the model of a statement into words, e.g. TruckAssignment
+// into "truck assignment", for not found messages.
+func recordName(stmt *gorm.Statement) string {
+	if stmt == nil || stmt.Schema == nil {
+		return "record"
+	}
+
+	var name strings.Builder
+	for i, char := range stmt.Schema.Name {
+		if unicode.IsUpper(char) {
+			if i != 0 {
+				name.WriteByte(' ')
+			}
+
+			char = unicode.ToLower(char)
+		}
+
+		name.WriteRune(char)
+	}
+
+	return name.String()
+}
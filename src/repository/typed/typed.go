@@ -0,0 +1,77 @@
+package typed
+
+import (
+	"context"
+
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+)
+
+// Repository is a typed view of an IRepository for the model T, so callers
+// get values back instead of filling pointers and can only preload the
+// associations T has.
+//
+// It runs on top of any IRepository, the sql and in memory ones included,
+// which stays available for code that hasn't moved to it yet.
+type Repository[T any] struct {
+	repo interfaces.IRepository
+}
+
+func New[T any](repo interfaces.IRepository) *Repository[T] {
+	return &Repository[T]{repo: repo}
+}
+
+func (r *Repository[T]) Get(ctx context.Context, id int32, preloads ...query.Preload[T]) (T, error) {
+	var record T
+
+	err := r.repo.FindById(ctx, &record, id, names(preloads)...)
+
+	return record, err
+}
+
+func (r *Repository[T]) List(ctx context.Context, opts query.Options, preloads ...query.Preload[T]) ([]T, int64, error) {
+	records := []T{}
+
+	opts.Preloads = append(opts.Preloads, names(preloads)...)
+
+	total, err := r.repo.FindAll(ctx, &records, opts)
+
+	return records, total, err
+}
+
+func (r *Repository[T]) Create(ctx context.Context, record *T) error {
+	return r.repo.Create(ctx, record)
+}
+
+// Patch sets the given columns of the record, zero values included, and
+// returns it as stored.
+func (r *Repository[T]) Patch(ctx context.Context, id int32, columns map[string]any, preloads ...query.Preload[T]) (T, error) {
+	var record T
+
+	err := r.repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		if len(columns) > 0 {
+			if err := tx.UpdateColumns(ctx, &record, id, columns); err != nil {
+				return err
+			}
+		}
+
+		return tx.FindById(ctx, &record, id, names(preloads)...)
+	})
+
+	return record, err
+}
+
+func (r *Repository[T]) Delete(ctx context.Context, id int32) error {
+	var record T
+
+	return r.repo.Delete(ctx, &record, id)
+}
+
+func names[T any](preloads []query.Preload[T]) []string {
+	names := make([]string, 0, len(preloads))
+	for _, preload := range preloads {
+		names = append(names, string(preload))
+	}
+
+	return names
+}
@@ -0,0 +1,52 @@
+package typed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/memory"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+
+	drivers := New[entities.Driver](repo)
+	trucks := New[entities.Truck](repo)
+
+	driver := entities.Driver{Name: "driver", LicenseNumber: "123", Status: entities.DriverActive}
+	assert.NoError(t, drivers.Create(ctx, &driver))
+
+	truck := entities.Truck{LicensePlate: "ABC", DriverID: &driver.ID}
+	assert.NoError(t, trucks.Create(ctx, &truck))
+
+	found, err := trucks.Get(ctx, truck.ID, entities.TruckDriver)
+	assert.NoError(t, err)
+	assert.Equal(t, "driver", found.Driver.Name)
+
+	listed, total, err := trucks.List(ctx, query.New().Where("licensePlate", query.Equal, "ABC"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Nil(t, listed[0].Driver)
+
+	// zero values are written too
+	patched, err := drivers.Patch(ctx, driver.ID, map[string]any{"license_number": ""})
+	assert.NoError(t, err)
+	assert.Empty(t, patched.LicenseNumber)
+	assert.Equal(t, "driver", patched.Name)
+
+	assert.NoError(t, trucks.Delete(ctx, truck.ID))
+
+	_, err = trucks.Get(ctx, truck.ID)
+	assert.True(t, apperr.Is(err, apperr.NotFound))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = drivers.Get(canceled, driver.ID)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,177 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/shopspring/decimal"
+)
+
+// ratios are rounded to this many places, totals are kept exact
+const precision = 4
+
+var hundred = decimal.NewFromInt(100)
+
+type Efficiency struct {
+	Trips          int              `json:"trips"`
+	Distance       decimal.Decimal  `json:"distance"`
+	FuelLiters     decimal.Decimal  `json:"fuelLiters"`
+	KmPerLiter     *decimal.Decimal `json:"kmPerLiter"`
+	LitersPer100Km *decimal.Decimal `json:"litersPer100Km"`
+}
+
+type TruckEfficiency struct {
+	TruckID      int32  `json:"truckId"`
+	LicensePlate string `json:"licensePlate"`
+	Efficiency
+}
+
+type DriverEfficiency struct {
+	DriverID int32  `json:"driverId"`
+	Name     string `json:"name"`
+	Efficiency
+}
+
+type EfficiencyReport struct {
+	From    *time.Time         `json:"from"`
+	To      *time.Time         `json:"to"`
+	Fleet   Efficiency         `json:"fleet"`
+	Trucks  []TruckEfficiency  `json:"trucks"`
+	Drivers []DriverEfficiency `json:"drivers"`
+}
+
+func (e *Efficiency) add(trip entities.Trip) {
+	e.Trips++
+	e.Distance = e.Distance.Add(trip.Distance)
+	e.FuelLiters = e.FuelLiters.Add(trip.FuelLiters)
+}
+
+func (e *Efficiency) computeRatios() {
+	if !e.FuelLiters.IsZero() {
+		kmPerLiter := e.Distance.DivRound(e.FuelLiters, precision)
+		e.KmPerLiter = &kmPerLiter
+	}
+
+	if !e.Distance.IsZero() {
+		litersPer100Km := e.FuelLiters.Mul(hundred).DivRound(e.Distance, precision)
+		e.LitersPer100Km = &litersPer100Km
+	}
+}
+
+// FuelEfficiency aggregates the trips started within [from, to] per truck, per
+// driver and for the whole fleet. Trips are attributed to the driver that had
+// the truck assigned when they started. Both bounds are optional.
+func FuelEfficiency(ctx context.Context, repo interfaces.IRepository, from *time.Time, to *time.Time) (EfficiencyReport, error) {
+	report := EfficiencyReport{
+		From:    from,
+		To:      to,
+		Trucks:  []TruckEfficiency{},
+		Drivers: []DriverEfficiency{},
+	}
+
+	byTruck := map[int32]*Efficiency{}
+	byDriver := map[int32]*Efficiency{}
+
+	opts := query.New()
+	opts.Limit = query.MaxLimit
+
+	if from != nil {
+		opts = opts.Where("startedAt", query.GreaterOrEqual, *from)
+	}
+
+	if to != nil {
+		opts = opts.Where("startedAt", query.LessOrEqual, *to)
+	}
+
+	// walk the trips in pages, only the aggregates are kept in memory
+	for {
+		trips := []entities.Trip{}
+		if _, err := repo.FindAll(ctx, &trips, opts); err != nil {
+			return report, err
+		}
+
+		for _, trip := range trips {
+			report.Fleet.add(trip)
+
+			if byTruck[trip.TruckID] == nil {
+				byTruck[trip.TruckID] = &Efficiency{}
+			}
+			byTruck[trip.TruckID].add(trip)
+
+			if trip.DriverID != nil {
+				if byDriver[*trip.DriverID] == nil {
+					byDriver[*trip.DriverID] = &Efficiency{}
+				}
+				byDriver[*trip.DriverID].add(trip)
+			}
+		}
+
+		if len(trips) < opts.Limit {
+			break
+		}
+
+		opts.Offset += len(trips)
+	}
+
+	report.Fleet.computeRatios()
+
+	trucks := []entities.Truck{}
+	if err := findByIds(ctx, repo, &trucks, byTruck); err != nil {
+		return report, err
+	}
+
+	for _, truck := range trucks {
+		efficiency := byTruck[truck.ID]
+		efficiency.computeRatios()
+
+		report.Trucks = append(report.Trucks, TruckEfficiency{
+			TruckID:      truck.ID,
+			LicensePlate: truck.LicensePlate,
+			Efficiency:   *efficiency,
+		})
+	}
+
+	drivers := []entities.Driver{}
+	if err := findByIds(ctx, repo, &drivers, byDriver); err != nil {
+		return report, err
+	}
+
+	for _, driver := range drivers {
+		efficiency := byDriver[driver.ID]
+		efficiency.computeRatios()
+
+		report.Drivers = append(report.Drivers, DriverEfficiency{
+			DriverID:   driver.ID,
+			Name:       driver.Name,
+			Efficiency: *efficiency,
+		})
+	}
+
+	return report, nil
+}
+
+func findByIds(ctx context.Context, repo interfaces.IRepository, target any, aggregates map[int32]*Efficiency) error {
+	if len(aggregates) == 0 {
+		return nil
+	}
+
+	ids := make([]int32, 0, len(aggregates))
+	for id := range aggregates {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// trips of deleted trucks and drivers still count for the fleet, so
+	// their rows are kept for the breakdown to add up to it
+	opts := query.New().Where("id", query.Equal, ids)
+	opts.Limit = 0
+	opts.IncludeDeleted = true
+
+	_, err := repo.FindAll(ctx, target, opts)
+	return err
+}
@@ -0,0 +1,163 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+)
+
+type Scope = string
+
+const (
+	TrucksRead       Scope = "trucks:read"
+	TrucksWrite      Scope = "trucks:write"
+	TrucksDelete     Scope = "trucks:delete"
+	DriversRead      Scope = "drivers:read"
+	DriversWrite     Scope = "drivers:write"
+	DriversDelete    Scope = "drivers:delete"
+	AssignmentsRead  Scope = "assignments:read"
+	AssignmentsWrite Scope = "assignments:write"
+	TripsRead        Scope = "trips:read"
+	TripsWrite       Scope = "trips:write"
+	AnalyticsRead    Scope = "analytics:read"
+	MaintenanceRead  Scope = "maintenance:read"
+	MaintenanceWrite Scope = "maintenance:write"
+	FuelRead         Scope = "fuel:read"
+	FuelWrite        Scope = "fuel:write"
+)
+
+var Scopes = []Scope{
+	TrucksRead, TrucksWrite, TrucksDelete,
+	DriversRead, DriversWrite, DriversDelete,
+	AssignmentsRead, AssignmentsWrite,
+	TripsRead, TripsWrite,
+	AnalyticsRead,
+	MaintenanceRead, MaintenanceWrite,
+	FuelRead, FuelWrite,
+}
+
+const (
+	keyPrefix = "gbx_"
+	// last use is only persisted this often, not on every request
+	touchInterval = time.Minute
+)
+
+var (
+	ErrInvalidKey   = apperr.New(apperr.Unauthorized, "invalid api key")
+	ErrUnknownScope = apperr.New(apperr.Validation, "unknown scope")
+)
+
+// Create stores a new key and returns its plain value, which is never
+// persisted and can't be recovered afterwards.
+func Create(ctx context.Context, repo interfaces.IRepository, key *entities.ApiKey) (string, error) {
+	for _, scope := range key.Scopes {
+		if !isKnownScope(scope) {
+			return "", fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+
+	prefix, err := randomString(6, hex.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+
+	key.Prefix = prefix
+	key.SecretHash = hash(secret)
+	key.LastUsedAt = nil
+	key.RevokedAt = nil
+
+	if err := repo.Create(ctx, key); err != nil {
+		return "", err
+	}
+
+	return keyPrefix + prefix + "." + secret, nil
+}
+
+// Verify looks the key up on every call, so revocations apply right away.
+func Verify(ctx context.Context, repo interfaces.IRepository, raw string) (entities.ApiKey, error) {
+	key := entities.ApiKey{}
+
+	prefix, secret, found := strings.Cut(strings.TrimPrefix(raw, keyPrefix), ".")
+	if !found || prefix == "" || secret == "" {
+		return key, ErrInvalidKey
+	}
+
+	if err := repo.FindFirst(ctx, &key, query.New().Where("prefix", query.Equal, prefix)); err != nil {
+		return key, err
+	}
+
+	if key.ID == 0 || subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(hash(secret))) != 1 {
+		return entities.ApiKey{}, ErrInvalidKey
+	}
+
+	now := time.Now()
+
+	if key.RevokedAt != nil {
+		return entities.ApiKey{}, fmt.Errorf("%w: revoked", ErrInvalidKey)
+	}
+
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+		return entities.ApiKey{}, fmt.Errorf("%w: expired", ErrInvalidKey)
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > touchInterval {
+		if err := repo.UpdateColumn(ctx, &entities.ApiKey{}, key.ID, "last_used_at", now); err != nil {
+			return entities.ApiKey{}, err
+		}
+
+		key.LastUsedAt = &now
+	}
+
+	return key, nil
+}
+
+func Revoke(ctx context.Context, repo interfaces.IRepository, key *entities.ApiKey) error {
+	now := time.Now()
+
+	if err := repo.UpdateColumn(ctx, key, key.ID, "revoked_at", now); err != nil {
+		return err
+	}
+
+	key.RevokedAt = &now
+	return nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, known := range Scopes {
+		if known == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int, encode func([]byte) string) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return encode(buf), nil
+}
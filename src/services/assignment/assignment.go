@@ -0,0 +1,269 @@
+package assignment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/mdelclaro/gobrax/src/services/license"
+)
+
+const (
+	ReasonAssigned   = "assigned"
+	ReasonReassigned = "reassigned"
+	ReasonUnassigned = "unassigned"
+	ReasonMoved      = "moved"
+	ReasonSwapped    = "swapped"
+	ReasonDeleted    = "deleted"
+
+	// the driver left the active status
+	ReasonDriverInactive = "driver_inactive"
+)
+
+var (
+	ErrNoDriver            = apperr.New(apperr.Validation, "truck has no driver")
+	ErrInvalidDriver       = apperr.New(apperr.Validation, "invalid driver provided")
+	ErrDriverNotActive     = apperr.New(apperr.Validation, "driver is not active")
+	ErrTruckOccupied       = apperr.New(apperr.Conflict, "truck already has a driver")
+	ErrDriverAssigned      = apperr.New(apperr.Conflict, "driver is already assigned to another truck")
+	ErrDestinationNotFound = apperr.New(apperr.NotFound, "destination truck not found")
+)
+
+// Assign attaches the driver to the truck and records it in the assignment
+// history, closing whatever assignment the truck had open.
+// The truck and the driver are locked before anything is checked, so
+// concurrent assignments involving either of them run one after the other
+// and the later ones see the outcome of the earlier, reported as a conflict.
+func Assign(ctx context.Context, repo interfaces.IRepository, truck *entities.Truck, driverID int32, reason string) error {
+	return repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		if err := tx.FindByIdForUpdate(ctx, truck, truck.ID); err != nil {
+			return err
+		}
+
+		driver := entities.Driver{}
+		if err := tx.FindByIdForUpdate(ctx, &driver, driverID); err != nil {
+			if apperr.Is(err, apperr.NotFound) {
+				return ErrInvalidDriver
+			}
+
+			return err
+		}
+
+		if driver.Status != entities.DriverActive {
+			return ErrDriverNotActive
+		}
+
+		if err := license.CheckEligible(&driver, truck, time.Now()); err != nil {
+			return err
+		}
+
+		// assigning the current driver again would only add noise to the history
+		if truck.DriverID != nil && *truck.DriverID == driverID {
+			return nil
+		}
+
+		current, err := TruckOfDriver(ctx, tx, driverID)
+		if err != nil {
+			return err
+		}
+
+		if current.ID != 0 {
+			return fmt.Errorf("%w: truck %d", ErrDriverAssigned, current.ID)
+		}
+
+		now := time.Now()
+
+		if err := closeOpen(ctx, tx, truck.ID, now, ReasonReassigned); err != nil {
+			return err
+		}
+
+		return attach(ctx, tx, truck, driverID, now, reason)
+	})
+}
+
+// Unassign releases the driver of the truck, ending its open assignment.
+func Unassign(ctx context.Context, repo interfaces.IRepository, truck *entities.Truck, reason string) error {
+	return repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		if err := tx.FindByIdForUpdate(ctx, truck, truck.ID); err != nil {
+			return err
+		}
+
+		if truck.DriverID == nil {
+			return ErrNoDriver
+		}
+
+		return detach(ctx, tx, truck, time.Now(), reason)
+	})
+}
+
+// Move transfers the driver of from to to. When to already has a driver it is
+// only accepted with swap, in which case that driver is moved to from.
+func Move(ctx context.Context, repo interfaces.IRepository, from *entities.Truck, to *entities.Truck, swap bool) error {
+	return repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		if err := lockPair(ctx, tx, from, to); err != nil {
+			return err
+		}
+
+		if from.DriverID == nil {
+			return ErrNoDriver
+		}
+
+		if to.DriverID != nil && !swap {
+			return ErrTruckOccupied
+		}
+
+		now := time.Now()
+		driverID := *from.DriverID
+		swappedID := to.DriverID
+
+		// each driver has to be allowed to drive the truck they end up in
+		if err := checkEligible(ctx, tx, driverID, to, now); err != nil {
+			return err
+		}
+
+		if swappedID != nil {
+			if err := checkEligible(ctx, tx, *swappedID, from, now); err != nil {
+				return err
+			}
+		}
+
+		// drivers are unique per truck, so both have to be released
+		// before either of them can be attached again
+		if err := detach(ctx, tx, from, now, ReasonMoved); err != nil {
+			return err
+		}
+
+		if swappedID != nil {
+			if err := detach(ctx, tx, to, now, ReasonSwapped); err != nil {
+				return err
+			}
+		}
+
+		if err := attach(ctx, tx, to, driverID, now, ReasonMoved); err != nil {
+			return err
+		}
+
+		if swappedID != nil {
+			return attach(ctx, tx, from, *swappedID, now, ReasonSwapped)
+		}
+
+		return nil
+	})
+}
+
+// TruckOfDriver returns the truck the driver is currently attached to, or an
+// empty truck when there is none.
+func TruckOfDriver(ctx context.Context, repo interfaces.IRepository, driverID int32) (entities.Truck, error) {
+	truck := entities.Truck{}
+	err := repo.FindFirst(ctx, &truck, query.New().Where("driverId", query.Equal, driverID))
+
+	return truck, err
+}
+
+// DriverAt returns the assignment that was open on the truck at the given
+// time, or an empty assignment when nobody had it.
+func DriverAt(ctx context.Context, repo interfaces.IRepository, truckID int32, at time.Time) (entities.TruckAssignment, error) {
+	assignment := entities.TruckAssignment{}
+
+	opts := query.New("Driver").
+		Where("truckId", query.Equal, truckID).
+		Where("startedAt", query.LessOrEqual, at).
+		OrderBy("startedAt", true).
+		OrderBy("id", true)
+
+	if err := repo.FindFirst(ctx, &assignment, opts); err != nil {
+		return assignment, err
+	}
+
+	if assignment.EndedAt != nil && !assignment.EndedAt.After(at) {
+		return entities.TruckAssignment{}, nil
+	}
+
+	return assignment, nil
+}
+
+// lockPair locks both trucks in id order, so two moves between the same
+// trucks in opposite directions wait for each other instead of deadlocking.
+func lockPair(ctx context.Context, tx interfaces.IRepository, from *entities.Truck, to *entities.Truck) error {
+	first, second := from, to
+	if to.ID < from.ID {
+		first, second = to, from
+	}
+
+	for _, truck := range []*entities.Truck{first, second} {
+		if err := tx.FindByIdForUpdate(ctx, truck, truck.ID); err != nil {
+			if truck == to && apperr.Is(err, apperr.NotFound) {
+				return ErrDestinationNotFound
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkEligible(ctx context.Context, tx interfaces.IRepository, driverID int32, truck *entities.Truck, at time.Time) error {
+	driver := entities.Driver{}
+	if err := tx.FindById(ctx, &driver, driverID); err != nil {
+		return err
+	}
+
+	return license.CheckEligible(&driver, truck, at)
+}
+
+func attach(ctx context.Context, repo interfaces.IRepository, truck *entities.Truck, driverID int32, at time.Time, reason string) error {
+	if err := repo.UpdateColumn(ctx, truck, truck.ID, "driver_id", driverID); err != nil {
+		return err
+	}
+
+	truck.DriverID = &driverID
+	truck.Driver = nil
+
+	return repo.Create(ctx, &entities.TruckAssignment{
+		TruckID:   truck.ID,
+		DriverID:  driverID,
+		StartedAt: at,
+		Reason:    reason,
+	})
+}
+
+func detach(ctx context.Context, repo interfaces.IRepository, truck *entities.Truck, at time.Time, reason string) error {
+	if err := closeOpen(ctx, repo, truck.ID, at, reason); err != nil {
+		return err
+	}
+
+	if err := repo.UpdateColumn(ctx, truck, truck.ID, "driver_id", nil); err != nil {
+		return err
+	}
+
+	truck.DriverID = nil
+	truck.Driver = nil
+
+	return nil
+}
+
+func closeOpen(ctx context.Context, repo interfaces.IRepository, truckID int32, at time.Time, reason string) error {
+	open := entities.TruckAssignment{}
+
+	opts := query.New().
+		Where("truckId", query.Equal, truckID).
+		Where("endedAt", query.Equal, nil)
+
+	if err := repo.FindFirst(ctx, &open, opts); err != nil {
+		return err
+	}
+
+	if open.ID == 0 {
+		return nil
+	}
+
+	open.EndedAt = &at
+	open.EndReason = reason
+
+	return repo.Update(ctx, &open)
+}
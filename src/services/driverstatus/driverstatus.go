@@ -0,0 +1,90 @@
+package driverstatus
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/services/assignment"
+)
+
+var ErrReasonRequired = apperr.New(apperr.Validation, "reason is required")
+
+// transitions lists the statuses a driver can go to from each status.
+// Terminated is final.
+var transitions = map[entities.DriverStatus][]entities.DriverStatus{
+	entities.DriverOnboarding: {entities.DriverActive, entities.DriverTerminated},
+	entities.DriverActive:     {entities.DriverSuspended, entities.DriverOnLeave, entities.DriverTerminated},
+	entities.DriverSuspended:  {entities.DriverActive, entities.DriverTerminated},
+	entities.DriverOnLeave:    {entities.DriverActive, entities.DriverTerminated},
+}
+
+// CanTransition reports whether a driver can go from one status to the other.
+func CanTransition(from entities.DriverStatus, to entities.DriverStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Change moves the driver to the given status and records who did it and
+// why. A driver leaving active is released from their truck in the same
+// transaction, as only active drivers can be assigned.
+func Change(ctx context.Context, repo interfaces.IRepository, driver *entities.Driver, to entities.DriverStatus, reason string, changedBy string) (entities.DriverStatusChange, error) {
+	change := entities.DriverStatusChange{}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return change, ErrReasonRequired
+	}
+
+	err := repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		if err := tx.FindByIdForUpdate(ctx, driver, driver.ID); err != nil {
+			return err
+		}
+
+		if !CanTransition(driver.Status, to) {
+			return apperr.New(apperr.Conflict, "driver can't go from %s to %s", driver.Status, to)
+		}
+
+		if driver.Status == entities.DriverActive {
+			truck, err := assignment.TruckOfDriver(ctx, tx, driver.ID)
+			if err != nil {
+				return err
+			}
+
+			if truck.ID != 0 {
+				if err := assignment.Unassign(ctx, tx, &truck, assignment.ReasonDriverInactive); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.UpdateColumn(ctx, &entities.Driver{}, driver.ID, "status", to); err != nil {
+			return err
+		}
+
+		change = entities.DriverStatusChange{
+			DriverID:  driver.ID,
+			From:      driver.Status,
+			To:        to,
+			Reason:    reason,
+			ChangedBy: changedBy,
+			ChangedAt: time.Now(),
+		}
+
+		if err := tx.Create(ctx, &change); err != nil {
+			return err
+		}
+
+		return tx.FindById(ctx, driver, driver.ID)
+	})
+
+	return change, err
+}
@@ -0,0 +1,206 @@
+package fuel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	precision = 4
+
+	// fills averaged for the km per liter the truck usually does
+	baselineFills = 5
+	// fewer than these with a km per liter and there's no baseline
+	minBaselineFills = 3
+	// fills at different stations closer than this are suspicious
+	stationHopWindow = 30 * time.Minute
+)
+
+// a fill doing less than this share of the baseline km per liter is flagged
+var consumptionThreshold = decimal.RequireFromString("0.7")
+
+var ErrInvalidTransition = apperr.New(apperr.Conflict, "invalid fuel alert transition")
+
+// transitions lists the statuses each status can move to.
+var transitions = map[entities.FuelAlertStatus][]entities.FuelAlertStatus{
+	entities.FuelAlertOpen:         {entities.FuelAlertAcknowledged, entities.FuelAlertDismissed},
+	entities.FuelAlertAcknowledged: {entities.FuelAlertDismissed},
+}
+
+// detect sets the distance and km per liter of the fill since the previous
+// one of the truck and returns the alerts it raises.
+func detect(ctx context.Context, tx interfaces.IRepository, truck entities.Truck, transaction *entities.FuelTransaction) ([]entities.FuelAlert, error) {
+	alerts := []entities.FuelAlert{}
+
+	if truck.TankCapacity.IsPositive() && transaction.Liters.GreaterThan(truck.TankCapacity) {
+		alerts = append(alerts, entities.FuelAlert{
+			Kind:    entities.FuelAlertOverCapacity,
+			Details: fmt.Sprintf("filled %s liters, the tank holds %s", transaction.Liters, truck.TankCapacity),
+		})
+	}
+
+	previous := []entities.FuelTransaction{}
+	opts := query.New().
+		Where("truckId", query.Equal, transaction.TruckID).
+		Where("transactedAt", query.LessOrEqual, transaction.TransactedAt).
+		OrderBy("transactedAt", true).
+		OrderBy("id", true)
+	opts.Limit = baselineFills + 1
+
+	if _, err := tx.FindAll(ctx, &previous, opts); err != nil {
+		return nil, err
+	}
+
+	transaction.Distance = nil
+	transaction.KmPerLiter = nil
+
+	if len(previous) > 0 {
+		last := previous[0]
+
+		if last.Odometer.IsPositive() && transaction.Odometer.IsPositive() && !transaction.Odometer.LessThan(last.Odometer) {
+			distance := transaction.Odometer.Sub(last.Odometer)
+			kmPerLiter := distance.DivRound(transaction.Liters, precision)
+
+			transaction.Distance = &distance
+			transaction.KmPerLiter = &kmPerLiter
+		}
+
+		if transaction.Distance != nil && transaction.Distance.IsZero() {
+			alerts = append(alerts, entities.FuelAlert{
+				RelatedTransactionID: &last.ID,
+				Kind:                 entities.FuelAlertNoDistance,
+				Details:              fmt.Sprintf("odometer still at %s since the previous fill", transaction.Odometer),
+			})
+		}
+	}
+
+	if alert, ok := highConsumption(previous, transaction); ok {
+		alerts = append(alerts, alert)
+	}
+
+	hops, err := stationHops(ctx, tx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(alerts, hops...), nil
+}
+
+// highConsumption compares the km per liter of the fill with the average of
+// the previous fills that have one.
+func highConsumption(previous []entities.FuelTransaction, transaction *entities.FuelTransaction) (entities.FuelAlert, bool) {
+	if transaction.KmPerLiter == nil || transaction.Distance.IsZero() {
+		return entities.FuelAlert{}, false
+	}
+
+	sum := decimal.Zero
+	count := 0
+
+	for _, fill := range previous {
+		if fill.KmPerLiter == nil || count == baselineFills {
+			continue
+		}
+
+		sum = sum.Add(*fill.KmPerLiter)
+		count++
+	}
+
+	if count < minBaselineFills {
+		return entities.FuelAlert{}, false
+	}
+
+	baseline := sum.DivRound(decimal.NewFromInt(int64(count)), precision)
+	if !transaction.KmPerLiter.LessThan(baseline.Mul(consumptionThreshold)) {
+		return entities.FuelAlert{}, false
+	}
+
+	return entities.FuelAlert{
+		Kind:    entities.FuelAlertHighConsumption,
+		Details: fmt.Sprintf("did %s km/l, usually %s km/l", transaction.KmPerLiter, baseline),
+	}, true
+}
+
+// stationHops flags the fills of the truck at other stations around the
+// same time.
+func stationHops(ctx context.Context, tx interfaces.IRepository, transaction *entities.FuelTransaction) ([]entities.FuelAlert, error) {
+	alerts := []entities.FuelAlert{}
+	if transaction.Station == "" {
+		return alerts, nil
+	}
+
+	nearby := []entities.FuelTransaction{}
+	opts := query.New().
+		Where("truckId", query.Equal, transaction.TruckID).
+		Where("transactedAt", query.GreaterOrEqual, transaction.TransactedAt.Add(-stationHopWindow)).
+		Where("transactedAt", query.LessOrEqual, transaction.TransactedAt.Add(stationHopWindow)).
+		OrderBy("transactedAt", false)
+	opts.Limit = 0
+
+	if _, err := tx.FindAll(ctx, &nearby, opts); err != nil {
+		return nil, err
+	}
+
+	for _, fill := range nearby {
+		if fill.Station == "" || fill.Station == transaction.Station {
+			continue
+		}
+
+		gap := transaction.TransactedAt.Sub(fill.TransactedAt).Abs()
+		alerts = append(alerts, entities.FuelAlert{
+			RelatedTransactionID: &fill.ID,
+			Kind:                 entities.FuelAlertStationHop,
+			Details:              fmt.Sprintf("filled at %s %s apart from %s", transaction.Station, gap, fill.Station),
+		})
+	}
+
+	return alerts, nil
+}
+
+// Resolve acknowledges or dismisses the alert, recording who did it. A
+// dismissed alert is final.
+func Resolve(ctx context.Context, repo interfaces.IRepository, alert *entities.FuelAlert, to entities.FuelAlertStatus, note string, resolvedBy string) error {
+	return repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		if err := tx.FindByIdForUpdate(ctx, alert, alert.ID); err != nil {
+			return err
+		}
+
+		if !canTransition(alert.Status, to) {
+			return fmt.Errorf("%w: alert can't go from %s to %s", ErrInvalidTransition, alert.Status, to)
+		}
+
+		now := time.Now()
+		values := map[string]any{
+			"status":      to,
+			"resolved_by": resolvedBy,
+			"resolved_at": now,
+		}
+
+		if note = strings.TrimSpace(note); note != "" {
+			values["note"] = note
+		}
+
+		if err := tx.UpdateColumns(ctx, &entities.FuelAlert{}, alert.ID, values); err != nil {
+			return err
+		}
+
+		return tx.FindById(ctx, alert, alert.ID)
+	})
+}
+
+func canTransition(from entities.FuelAlertStatus, to entities.FuelAlertStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
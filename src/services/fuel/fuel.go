@@ -0,0 +1,125 @@
+package fuel
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/services/assignment"
+	"github.com/shopspring/decimal"
+)
+
+// rounding allowed between the total and liters times unit price
+var totalTolerance = decimal.RequireFromString("0.05")
+
+// Validate checks the amounts of a fill and completes its prices.
+func Validate(transaction *entities.FuelTransaction) error {
+	if !transaction.Liters.IsPositive() {
+		return apperr.New(apperr.Validation, "liters must be positive")
+	}
+
+	amounts := []struct {
+		field string
+		value decimal.Decimal
+	}{
+		{FieldUnitPrice, transaction.UnitPrice},
+		{FieldTotal, transaction.Total},
+		{FieldOdometer, transaction.Odometer},
+	}
+
+	for _, amount := range amounts {
+		if amount.value.IsNegative() {
+			return apperr.New(apperr.Validation, "%s can't be negative", amount.field)
+		}
+	}
+
+	if transaction.TransactedAt.After(time.Now()) {
+		return apperr.New(apperr.Validation, "transactedAt can't be in the future")
+	}
+
+	return completePrices(transaction)
+}
+
+// Record stores a fill of a truck, with the driver who had it at the time
+// when none is given, comparing it with the previous fills and raising the
+// alerts it deserves.
+func Record(ctx context.Context, repo interfaces.IRepository, transaction *entities.FuelTransaction) error {
+	transaction.Fingerprint = fingerprint(*transaction)
+
+	if transaction.DriverID == nil {
+		current, err := assignment.DriverAt(ctx, repo, transaction.TruckID, transaction.TransactedAt)
+		if err != nil {
+			return err
+		}
+
+		if current.ID != 0 {
+			transaction.DriverID = &current.DriverID
+		}
+	}
+
+	return repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		// fills of a truck are compared one at a time
+		truck := entities.Truck{}
+		if err := tx.FindByIdForUpdate(ctx, &truck, transaction.TruckID); err != nil {
+			return err
+		}
+
+		alerts, err := detect(ctx, tx, truck, transaction)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(ctx, transaction); err != nil {
+			return err
+		}
+
+		for i := range alerts {
+			alerts[i].TruckID = transaction.TruckID
+			alerts[i].TransactionID = transaction.ID
+
+			if err := tx.Create(ctx, &alerts[i]); err != nil {
+				return err
+			}
+		}
+
+		transaction.Alerts = alerts
+
+		return nil
+	})
+}
+
+// completePrices derives the unit price or the total from the other one,
+// when both are given they have to agree.
+func completePrices(transaction *entities.FuelTransaction) error {
+	switch {
+	case transaction.Total.IsZero() && transaction.UnitPrice.IsPositive():
+		transaction.Total = transaction.Liters.Mul(transaction.UnitPrice).Round(2)
+	case transaction.UnitPrice.IsZero() && transaction.Total.IsPositive():
+		transaction.UnitPrice = transaction.Total.DivRound(transaction.Liters, 4)
+	case transaction.Total.IsPositive() && transaction.UnitPrice.IsPositive():
+		expected := transaction.Liters.Mul(transaction.UnitPrice)
+		if expected.Sub(transaction.Total).Abs().GreaterThan(totalTolerance) {
+			return apperr.New(apperr.Validation, "total %s doesn't match liters times unitPrice %s", transaction.Total, expected.Round(2))
+		}
+	}
+
+	return nil
+}
+
+func fingerprint(transaction entities.FuelTransaction) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		fmt.Sprint(transaction.TruckID),
+		transaction.TransactedAt.UTC().Format(time.RFC3339Nano),
+		transaction.Liters.String(),
+		transaction.Station,
+		transaction.CardNumber,
+	}, "|")))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,448 @@
+package fuel
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/shopspring/decimal"
+)
+
+// MaxImportRows caps the lines of a single statement, bigger ones have to
+// be split.
+const MaxImportRows = 5000
+
+// fields a statement column can be mapped to
+const (
+	FieldLicensePlate        = "licensePlate"
+	FieldTransactedAt        = "transactedAt"
+	FieldLiters              = "liters"
+	FieldUnitPrice           = "unitPrice"
+	FieldTotal               = "total"
+	FieldStation             = "station"
+	FieldCardNumber          = "cardNumber"
+	FieldOdometer            = "odometer"
+	FieldDriverLicenseNumber = "driverLicenseNumber"
+)
+
+var (
+	fields         = []string{FieldLicensePlate, FieldTransactedAt, FieldLiters, FieldUnitPrice, FieldTotal, FieldStation, FieldCardNumber, FieldOdometer, FieldDriverLicenseNumber}
+	requiredFields = []string{FieldLicensePlate, FieldTransactedAt, FieldLiters}
+)
+
+type RowStatus string
+
+const (
+	RowImported  RowStatus = "imported"
+	RowDuplicate RowStatus = "duplicate"
+	RowRejected  RowStatus = "rejected"
+)
+
+var (
+	ErrInvalidFile    = apperr.New(apperr.Validation, "invalid csv")
+	ErrInvalidMapping = apperr.New(apperr.Validation, "invalid column mapping")
+
+	errDuplicate = errors.New("already imported")
+)
+
+type ImportOptions struct {
+	// Mapping maps fields to the header of their column, fields left out
+	// are looked up by their own name
+	Mapping   map[string]string
+	Delimiter rune
+	// TimeLayout parses transactedAt, RFC 3339 by default, in Location
+	// when it has no zone
+	TimeLayout string
+	Location   *time.Location
+	// DecimalComma reads numbers written as 1.234,56
+	DecimalComma bool
+}
+
+type RowResult struct {
+	Line          int       `json:"line"`
+	Status        RowStatus `json:"status"`
+	TransactionID int32     `json:"transactionId,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	// kinds of the alerts raised by the transaction
+	Alerts []entities.FuelAlertKind `json:"alerts,omitempty"`
+}
+
+type ImportReport struct {
+	Imported   int         `json:"imported"`
+	Duplicates int         `json:"duplicates"`
+	Rejected   int         `json:"rejected"`
+	Rows       []RowResult `json:"rows"`
+}
+
+func (r *ImportReport) add(row RowResult) {
+	switch row.Status {
+	case RowImported:
+		r.Imported++
+	case RowDuplicate:
+		r.Duplicates++
+	case RowRejected:
+		r.Rejected++
+	}
+
+	r.Rows = append(r.Rows, row)
+}
+
+// Import reads a fuel card statement and stores each line as a transaction
+// of the truck with its license plate. Lines are independent: one being
+// rejected or already imported doesn't stop the others, the report tells
+// what happened to each.
+func Import(ctx context.Context, repo interfaces.IRepository, r io.Reader, opts ImportOptions, recordedBy string) (ImportReport, error) {
+	report := ImportReport{Rows: []RowResult{}}
+
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+
+	if opts.TimeLayout == "" {
+		opts.TimeLayout = time.RFC3339
+	}
+
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = opts.Delimiter
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return report, fmt.Errorf("%w: %s", ErrInvalidFile, err.Error())
+	}
+
+	columns, err := resolveColumns(header, opts.Mapping)
+	if err != nil {
+		return report, err
+	}
+
+	// the whole file is read first, so one too big is refused before any
+	// of its lines is stored
+	lines, err := readLines(reader)
+	if err != nil {
+		return report, err
+	}
+
+	imp := importer{
+		repo:       repo,
+		opts:       opts,
+		columns:    columns,
+		recordedBy: recordedBy,
+		trucks:     map[string]entities.Truck{},
+		seen:       map[string]bool{},
+	}
+
+	for _, line := range lines {
+		if line.err != nil {
+			report.add(RowResult{Line: line.number, Status: RowRejected, Error: line.err.Error()})
+			continue
+		}
+
+		row := RowResult{Line: line.number, Status: RowImported}
+
+		transaction, err := imp.row(ctx, line.record)
+		switch {
+		case errors.Is(err, errDuplicate):
+			row.Status = RowDuplicate
+		case apperr.Is(err, apperr.Validation), apperr.Is(err, apperr.Conflict):
+			row.Status = RowRejected
+			row.Error = err.Error()
+		case err != nil:
+			return report, err
+		default:
+			row.TransactionID = transaction.ID
+
+			for _, alert := range transaction.Alerts {
+				row.Alerts = append(row.Alerts, alert.Kind)
+			}
+		}
+
+		report.add(row)
+	}
+
+	return report, nil
+}
+
+// line is a line of the statement, or why it couldn't be parsed.
+type line struct {
+	number int
+	record []string
+	err    error
+}
+
+// readLines reads the lines after the header, refusing files with more than
+// MaxImportRows of them.
+func readLines(reader *csv.Reader) ([]line, error) {
+	lines := []line{}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+
+		if len(lines) == MaxImportRows {
+			return nil, fmt.Errorf("%w: more than %d lines", ErrInvalidFile, MaxImportRows)
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidFile, err.Error())
+			}
+
+			lines = append(lines, line{number: parseErr.StartLine, err: parseErr.Err})
+			continue
+		}
+
+		number, _ := reader.FieldPos(0)
+		lines = append(lines, line{number: number, record: record})
+	}
+}
+
+// resolveColumns finds the index of the column of each field.
+func resolveColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	for field := range mapping {
+		if !isField(field) {
+			return nil, fmt.Errorf("%w: unknown field %s", ErrInvalidMapping, field)
+		}
+	}
+
+	indexes := map[string]int{}
+	for i, name := range header {
+		// excel writes a byte order mark before the first header
+		indexes[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+
+	columns := map[string]int{}
+	for _, field := range fields {
+		name := field
+		if mapped, ok := mapping[field]; ok {
+			name = mapped
+		}
+
+		if index, ok := indexes[name]; ok {
+			columns[field] = index
+		}
+	}
+
+	for _, field := range requiredFields {
+		if _, ok := columns[field]; !ok {
+			name := field
+			if mapped, ok := mapping[field]; ok {
+				name = mapped
+			}
+
+			return nil, fmt.Errorf("%w: column %s for %s not found", ErrInvalidMapping, name, field)
+		}
+	}
+
+	return columns, nil
+}
+
+func isField(name string) bool {
+	for _, field := range fields {
+		if field == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+type importer struct {
+	repo       interfaces.IRepository
+	opts       ImportOptions
+	columns    map[string]int
+	recordedBy string
+
+	// trucks by license plate, an empty one when there's none
+	trucks map[string]entities.Truck
+	// fingerprints of the lines imported so far
+	seen map[string]bool
+}
+
+func (imp *importer) row(ctx context.Context, record []string) (entities.FuelTransaction, error) {
+	transaction := entities.FuelTransaction{
+		Station:    imp.value(record, FieldStation),
+		CardNumber: imp.value(record, FieldCardNumber),
+		RecordedBy: imp.recordedBy,
+	}
+
+	plate := imp.value(record, FieldLicensePlate)
+	if plate == "" {
+		return transaction, apperr.New(apperr.Validation, "licensePlate is required")
+	}
+
+	truck, err := imp.truck(ctx, plate)
+	if err != nil {
+		return transaction, err
+	}
+
+	if truck.ID == 0 {
+		return transaction, apperr.New(apperr.Validation, "no truck with license plate %s", plate)
+	}
+
+	transaction.TruckID = truck.ID
+
+	transactedAt := imp.value(record, FieldTransactedAt)
+	transaction.TransactedAt, err = time.ParseInLocation(imp.opts.TimeLayout, transactedAt, imp.opts.Location)
+	if err != nil {
+		return transaction, apperr.New(apperr.Validation, "invalid transactedAt %q", transactedAt)
+	}
+
+	if transaction.Liters, err = imp.decimal(record, FieldLiters); err != nil {
+		return transaction, err
+	}
+
+	optional := []struct {
+		field  string
+		target *decimal.Decimal
+	}{
+		{FieldUnitPrice, &transaction.UnitPrice},
+		{FieldTotal, &transaction.Total},
+		{FieldOdometer, &transaction.Odometer},
+	}
+
+	for _, column := range optional {
+		if *column.target, err = imp.decimal(record, column.field); err != nil {
+			return transaction, err
+		}
+	}
+
+	if err := Validate(&transaction); err != nil {
+		return transaction, err
+	}
+
+	if err := imp.driver(ctx, record, &transaction); err != nil {
+		return transaction, err
+	}
+
+	transaction.Fingerprint = fingerprint(transaction)
+	if imp.seen[transaction.Fingerprint] {
+		return transaction, errDuplicate
+	}
+
+	imported, err := imp.imported(ctx, transaction.Fingerprint)
+	if err != nil {
+		return transaction, err
+	}
+
+	if imported {
+		return transaction, errDuplicate
+	}
+
+	if err := Record(ctx, imp.repo, &transaction); err != nil {
+		if !apperr.Is(err, apperr.Conflict) {
+			return transaction, err
+		}
+
+		// imported by someone else in the meantime, or a conflict of its
+		// own, e.g. its driver deleted meanwhile
+		imported, lookupErr := imp.imported(ctx, transaction.Fingerprint)
+		if lookupErr != nil {
+			return transaction, lookupErr
+		}
+
+		if imported {
+			return transaction, errDuplicate
+		}
+
+		return transaction, err
+	}
+
+	imp.seen[transaction.Fingerprint] = true
+
+	return transaction, nil
+}
+
+// imported reports whether a transaction with the fingerprint is stored.
+func (imp *importer) imported(ctx context.Context, fingerprint string) (bool, error) {
+	existing := entities.FuelTransaction{}
+	if err := imp.repo.FindFirst(ctx, &existing, query.New().Where("fingerprint", query.Equal, fingerprint)); err != nil {
+		return false, err
+	}
+
+	if existing.ID != 0 {
+		imp.seen[fingerprint] = true
+	}
+
+	return existing.ID != 0, nil
+}
+
+func (imp *importer) value(record []string, field string) string {
+	index, ok := imp.columns[field]
+	if !ok || index >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[index])
+}
+
+// decimal parses the number in the column of field, zero when it is empty.
+func (imp *importer) decimal(record []string, field string) (decimal.Decimal, error) {
+	raw := imp.value(record, field)
+	if raw == "" {
+		return decimal.Zero, nil
+	}
+
+	if imp.opts.DecimalComma {
+		raw = strings.ReplaceAll(raw, ".", "")
+		raw = strings.ReplaceAll(raw, ",", ".")
+	}
+
+	parsed, err := decimal.NewFromString(raw)
+	if err != nil {
+		return decimal.Zero, apperr.New(apperr.Validation, "invalid %s %q", field, imp.value(record, field))
+	}
+
+	return parsed, nil
+}
+
+func (imp *importer) truck(ctx context.Context, plate string) (entities.Truck, error) {
+	if truck, ok := imp.trucks[plate]; ok {
+		return truck, nil
+	}
+
+	truck := entities.Truck{}
+	if err := imp.repo.FindFirst(ctx, &truck, query.New().Where("licensePlate", query.Equal, plate)); err != nil {
+		return truck, err
+	}
+
+	imp.trucks[plate] = truck
+
+	return truck, nil
+}
+
+// driver sets the driver given by the statement, if any.
+func (imp *importer) driver(ctx context.Context, record []string, transaction *entities.FuelTransaction) error {
+	licenseNumber := imp.value(record, FieldDriverLicenseNumber)
+	if licenseNumber == "" {
+		return nil
+	}
+
+	driver := entities.Driver{}
+	if err := imp.repo.FindFirst(ctx, &driver, query.New().Where("licenseNumber", query.Equal, licenseNumber)); err != nil {
+		return err
+	}
+
+	if driver.ID == 0 {
+		return apperr.New(apperr.Validation, "no driver with license number %s", licenseNumber)
+	}
+
+	transaction.DriverID = &driver.ID
+
+	return nil
+}
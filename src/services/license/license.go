@@ -0,0 +1,63 @@
+package license
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+)
+
+var (
+	ErrInvalidLicense = apperr.New(apperr.Validation, "invalid license")
+	ErrExpired        = apperr.New(apperr.Validation, "driver license is expired")
+	ErrCategory       = apperr.New(apperr.Validation, "driver license doesn't cover the truck")
+)
+
+// covers lists the categories each license allows driving. Heavier
+// categories build on B, which has to be held first.
+var covers = map[entities.LicenseCategory][]entities.LicenseCategory{
+	entities.LicenseA:  {entities.LicenseA},
+	entities.LicenseB:  {entities.LicenseB},
+	entities.LicenseBE: {entities.LicenseB, entities.LicenseBE},
+	entities.LicenseC:  {entities.LicenseB, entities.LicenseC},
+	entities.LicenseCE: {entities.LicenseB, entities.LicenseBE, entities.LicenseC, entities.LicenseCE},
+	entities.LicenseD:  {entities.LicenseB, entities.LicenseD},
+	entities.LicenseDE: {entities.LicenseB, entities.LicenseBE, entities.LicenseD, entities.LicenseDE},
+}
+
+// Covers reports whether a license of category held allows driving a
+// vehicle that requires category required.
+func Covers(held entities.LicenseCategory, required entities.LicenseCategory) bool {
+	for _, category := range covers[held] {
+		if category == required {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks the license dates of a driver agree with each other.
+func Validate(driver *entities.Driver) error {
+	if driver.LicenseIssuedAt != nil && driver.LicenseExpiresAt != nil && !driver.LicenseExpiresAt.After(*driver.LicenseIssuedAt) {
+		return fmt.Errorf("%w: licenseExpiresAt must be after licenseIssuedAt", ErrInvalidLicense)
+	}
+
+	return nil
+}
+
+// CheckEligible fails when the driver can't take the truck at the given time,
+// because their license expired or doesn't cover the truck category.
+// Licenses without an expiry date aren't considered expired.
+func CheckEligible(driver *entities.Driver, truck *entities.Truck, at time.Time) error {
+	if driver.LicenseExpiresAt != nil && !driver.LicenseExpiresAt.After(at) {
+		return fmt.Errorf("%w: expired at %s", ErrExpired, driver.LicenseExpiresAt.Format(time.DateOnly))
+	}
+
+	if truck.RequiredLicenseCategory != "" && !Covers(driver.LicenseCategory, truck.RequiredLicenseCategory) {
+		return fmt.Errorf("%w: category %s required", ErrCategory, truck.RequiredLicenseCategory)
+	}
+
+	return nil
+}
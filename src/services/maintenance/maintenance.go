@@ -0,0 +1,249 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/shopspring/decimal"
+)
+
+const precision = 4
+
+var (
+	ErrInvalidPlan    = apperr.New(apperr.Validation, "invalid maintenance plan")
+	ErrInvalidEvent   = apperr.New(apperr.Validation, "invalid maintenance event")
+	ErrPlanOtherTruck = apperr.New(apperr.Validation, "maintenance plan belongs to another truck")
+)
+
+var day = decimal.NewFromInt(int64(24 * time.Hour))
+
+// Threshold is how close to falling due a plan has to be to be listed. Zero
+// values don't match anything but overdue plans.
+type Threshold struct {
+	Distance    decimal.Decimal
+	EngineHours decimal.Decimal
+	Days        int
+}
+
+type TruckDue struct {
+	TruckID      int32  `json:"truckId"`
+	LicensePlate string `json:"licensePlate"`
+	entities.ServiceDue
+}
+
+// CreatePlan stores a plan counting its intervals from the current state of
+// the truck.
+func CreatePlan(ctx context.Context, repo interfaces.IRepository, plan *entities.MaintenancePlan) error {
+	if err := validateIntervals(plan); err != nil {
+		return err
+	}
+
+	truck := entities.Truck{}
+	if err := repo.FindById(ctx, &truck, plan.TruckID); err != nil {
+		return err
+	}
+
+	plan.LastServiceAt = time.Now()
+	plan.LastServiceDistance = truck.DistanceTraveled
+	plan.LastServiceEngineHours = truck.EngineHours
+
+	return repo.Create(ctx, plan)
+}
+
+func validateIntervals(plan *entities.MaintenancePlan) error {
+	if plan.IntervalDistance.IsNegative() || plan.IntervalEngineHours.IsNegative() || plan.IntervalDays < 0 {
+		return fmt.Errorf("%w: intervals can't be negative", ErrInvalidPlan)
+	}
+
+	if plan.IntervalDistance.IsZero() && plan.IntervalEngineHours.IsZero() && plan.IntervalDays == 0 {
+		return fmt.Errorf("%w: intervalDistance, intervalEngineHours or intervalDays is required", ErrInvalidPlan)
+	}
+
+	return nil
+}
+
+// Log records a service done on a truck. Readings left empty are taken from
+// the truck, a higher engine hours reading is kept on it. When the event is
+// for a plan, the plan starts counting again from it.
+func Log(ctx context.Context, repo interfaces.IRepository, event *entities.MaintenanceEvent) error {
+	if event.DistanceTraveled.IsNegative() || event.EngineHours.IsNegative() {
+		return fmt.Errorf("%w: readings can't be negative", ErrInvalidEvent)
+	}
+
+	if event.PerformedAt.After(time.Now()) {
+		return fmt.Errorf("%w: performedAt can't be in the future", ErrInvalidEvent)
+	}
+
+	return repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		truck := entities.Truck{}
+		if err := tx.FindByIdForUpdate(ctx, &truck, event.TruckID); err != nil {
+			return err
+		}
+
+		if event.DistanceTraveled.IsZero() {
+			event.DistanceTraveled = truck.DistanceTraveled
+		}
+
+		if event.EngineHours.IsZero() {
+			event.EngineHours = truck.EngineHours
+		}
+
+		if event.EngineHours.GreaterThan(truck.EngineHours) {
+			if err := tx.UpdateColumn(ctx, &entities.Truck{}, truck.ID, "engine_hours", event.EngineHours); err != nil {
+				return err
+			}
+		}
+
+		if event.PlanID != nil {
+			plan := entities.MaintenancePlan{}
+			if err := tx.FindByIdForUpdate(ctx, &plan, *event.PlanID); err != nil {
+				return err
+			}
+
+			if plan.TruckID != truck.ID {
+				return fmt.Errorf("%w: plan %d is for truck %d", ErrPlanOtherTruck, plan.ID, plan.TruckID)
+			}
+
+			// services logged late don't reset a plan past a later one
+			if event.PerformedAt.After(plan.LastServiceAt) {
+				err := tx.UpdateColumns(ctx, &entities.MaintenancePlan{}, plan.ID, map[string]any{
+					"last_service_at":           event.PerformedAt,
+					"last_service_distance":     event.DistanceTraveled,
+					"last_service_engine_hours": event.EngineHours,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Create(ctx, event)
+	})
+}
+
+// Due computes when the plan falls due next for the truck as it is now.
+func Due(plan entities.MaintenancePlan, truck entities.Truck, now time.Time) entities.ServiceDue {
+	due := entities.ServiceDue{PlanID: plan.ID, Plan: plan.Name}
+
+	if plan.IntervalDistance.IsPositive() {
+		dueDistance := plan.LastServiceDistance.Add(plan.IntervalDistance)
+		remaining := dueDistance.Sub(truck.DistanceTraveled)
+
+		due.DueDistance = &dueDistance
+		due.RemainingDistance = &remaining
+		due.Progress = decimal.Max(due.Progress, truck.DistanceTraveled.Sub(plan.LastServiceDistance).DivRound(plan.IntervalDistance, precision))
+	}
+
+	if plan.IntervalEngineHours.IsPositive() {
+		dueEngineHours := plan.LastServiceEngineHours.Add(plan.IntervalEngineHours)
+		remaining := dueEngineHours.Sub(truck.EngineHours)
+
+		due.DueEngineHours = &dueEngineHours
+		due.RemainingEngineHours = &remaining
+		due.Progress = decimal.Max(due.Progress, truck.EngineHours.Sub(plan.LastServiceEngineHours).DivRound(plan.IntervalEngineHours, precision))
+	}
+
+	if plan.IntervalDays > 0 {
+		dueAt := plan.LastServiceAt.AddDate(0, 0, int(plan.IntervalDays))
+		elapsed := decimal.NewFromInt(int64(now.Sub(plan.LastServiceAt)))
+
+		due.DueAt = &dueAt
+		due.Progress = decimal.Max(due.Progress, elapsed.DivRound(day.Mul(decimal.NewFromInt32(plan.IntervalDays)), precision))
+	}
+
+	due.Overdue = due.Progress.GreaterThanOrEqual(decimal.NewFromInt(1))
+
+	return due
+}
+
+// NextDue returns the plan of the truck closest to falling due, nil when it
+// has none.
+func NextDue(ctx context.Context, repo interfaces.IRepository, truck entities.Truck) (*entities.ServiceDue, error) {
+	opts := query.New().Where("truckId", query.Equal, truck.ID)
+	opts.Limit = 0
+
+	plans := []entities.MaintenancePlan{}
+	if _, err := repo.FindAll(ctx, &plans, opts); err != nil {
+		return nil, err
+	}
+
+	var next *entities.ServiceDue
+
+	now := time.Now()
+	for _, plan := range plans {
+		due := Due(plan, truck, now)
+		if next == nil || due.Progress.GreaterThan(next.Progress) {
+			next = &due
+		}
+	}
+
+	return next, nil
+}
+
+// DueWithin lists the plans of every truck that are overdue or fall due
+// within the threshold, the most overdue first.
+func DueWithin(ctx context.Context, repo interfaces.IRepository, threshold Threshold, now time.Time) ([]TruckDue, error) {
+	result := []TruckDue{}
+
+	opts := query.New(string(entities.MaintenancePlanTruck)).OrderBy("id", false)
+	opts.Limit = query.MaxLimit
+
+	// walk the plans in pages, only the ones due are kept
+	for {
+		plans := []entities.MaintenancePlan{}
+		if _, err := repo.FindAll(ctx, &plans, opts); err != nil {
+			return nil, err
+		}
+
+		for _, plan := range plans {
+			if plan.Truck == nil {
+				continue
+			}
+
+			due := Due(plan, *plan.Truck, now)
+			if !within(due, threshold, now) {
+				continue
+			}
+
+			result = append(result, TruckDue{
+				TruckID:      plan.TruckID,
+				LicensePlate: plan.Truck.LicensePlate,
+				ServiceDue:   due,
+			})
+		}
+
+		if len(plans) < opts.Limit {
+			break
+		}
+
+		opts.Offset += len(plans)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Progress.GreaterThan(result[j].Progress)
+	})
+
+	return result, nil
+}
+
+func within(due entities.ServiceDue, threshold Threshold, now time.Time) bool {
+	if due.Overdue {
+		return true
+	}
+
+	if due.RemainingDistance != nil && threshold.Distance.IsPositive() && due.RemainingDistance.LessThanOrEqual(threshold.Distance) {
+		return true
+	}
+
+	if due.RemainingEngineHours != nil && threshold.EngineHours.IsPositive() && due.RemainingEngineHours.LessThanOrEqual(threshold.EngineHours) {
+		return true
+	}
+
+	return due.DueAt != nil && threshold.Days > 0 && !due.DueAt.After(now.AddDate(0, 0, threshold.Days))
+}
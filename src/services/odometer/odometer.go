@@ -0,0 +1,135 @@
+package odometer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/repository/query"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidReading = apperr.New(apperr.Validation, "invalid odometer reading")
+	ErrBackwards      = apperr.New(apperr.Validation, "odometer reading is lower than the last one")
+	ErrOutOfOrder     = apperr.New(apperr.Validation, "odometer reading is older than the last one")
+)
+
+// Last returns the latest reading of the truck, or an empty reading when it
+// has none.
+func Last(ctx context.Context, repo interfaces.IRepository, truckID int32) (entities.OdometerReading, error) {
+	last := entities.OdometerReading{}
+
+	opts := query.New().
+		Where("truckId", query.Equal, truckID).
+		OrderBy("readAt", true).
+		OrderBy("id", true)
+
+	err := repo.FindFirst(ctx, &last, opts)
+
+	return last, err
+}
+
+// Record accepts a reading that isn't lower nor older than the last one of
+// the truck, unless it is the first of a replaced meter, and adds the
+// distance since the last one to the truck.
+func Record(ctx context.Context, repo interfaces.IRepository, reading *entities.OdometerReading) error {
+	if reading.Value.IsNegative() {
+		return fmt.Errorf("%w: value can't be negative", ErrInvalidReading)
+	}
+
+	if reading.ReadAt.After(time.Now()) {
+		return fmt.Errorf("%w: readAt can't be in the future", ErrInvalidReading)
+	}
+
+	return repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		last, err := lockLast(ctx, tx, reading.TruckID)
+		if err != nil {
+			return err
+		}
+
+		return record(ctx, tx, last, reading)
+	})
+}
+
+// RecordTrip records the odometer at the end of the trip, and at its start
+// too when the truck has no readings yet, so its distance counts. Trips
+// ending before the last reading are already covered by the series and
+// aren't recorded.
+func RecordTrip(ctx context.Context, repo interfaces.IRepository, trip *entities.Trip) error {
+	return repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		last, err := lockLast(ctx, tx, trip.TruckID)
+		if err != nil {
+			return err
+		}
+
+		if last.ID != 0 && trip.EndedAt.Before(last.ReadAt) {
+			return nil
+		}
+
+		if last.ID == 0 {
+			start := tripReading(trip, trip.StartOdometer, trip.StartedAt)
+			if err := record(ctx, tx, last, &start); err != nil {
+				return err
+			}
+
+			last = start
+		}
+
+		end := tripReading(trip, trip.EndOdometer, trip.EndedAt)
+
+		return record(ctx, tx, last, &end)
+	})
+}
+
+func tripReading(trip *entities.Trip, value decimal.Decimal, at time.Time) entities.OdometerReading {
+	return entities.OdometerReading{
+		TruckID: trip.TruckID,
+		TripID:  &trip.ID,
+		Value:   value,
+		Source:  entities.OdometerTripClose,
+		ReadAt:  at,
+	}
+}
+
+// lockLast locks the truck, so its readings are checked one at a time, and
+// returns its last reading.
+func lockLast(ctx context.Context, tx interfaces.IRepository, truckID int32) (entities.OdometerReading, error) {
+	truck := entities.Truck{}
+	if err := tx.FindByIdForUpdate(ctx, &truck, truckID); err != nil {
+		return entities.OdometerReading{}, err
+	}
+
+	return Last(ctx, tx, truckID)
+}
+
+func record(ctx context.Context, tx interfaces.IRepository, last entities.OdometerReading, reading *entities.OdometerReading) error {
+	reading.Distance = decimal.Zero
+
+	if last.ID != 0 {
+		if reading.ReadAt.Before(last.ReadAt) {
+			return fmt.Errorf("%w: last one was read at %s", ErrOutOfOrder, last.ReadAt.Format(time.RFC3339))
+		}
+
+		if !reading.MeterReplacement {
+			if reading.Value.LessThan(last.Value) {
+				return fmt.Errorf("%w: last one was %s", ErrBackwards, last.Value)
+			}
+
+			reading.Distance = reading.Value.Sub(last.Value)
+		}
+	}
+
+	if err := tx.Create(ctx, reading); err != nil {
+		return err
+	}
+
+	if !reading.Distance.IsPositive() {
+		return nil
+	}
+
+	return tx.Increment(ctx, &entities.Truck{}, reading.TruckID, "distance_traveled", reading.Distance)
+}
@@ -0,0 +1,69 @@
+package trip
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+	"github.com/mdelclaro/gobrax/src/services/assignment"
+	"github.com/mdelclaro/gobrax/src/services/odometer"
+)
+
+var ErrInvalidTrip = apperr.New(apperr.Validation, "invalid trip")
+
+func Validate(trip *entities.Trip) error {
+	if trip.StartOdometer.IsNegative() {
+		return fmt.Errorf("%w: startOdometer can't be negative", ErrInvalidTrip)
+	}
+
+	if trip.EndOdometer.LessThan(trip.StartOdometer) {
+		return fmt.Errorf("%w: endOdometer can't be lower than startOdometer", ErrInvalidTrip)
+	}
+
+	if trip.FuelLiters.IsNegative() {
+		return fmt.Errorf("%w: fuelLiters can't be negative", ErrInvalidTrip)
+	}
+
+	if !trip.EndedAt.After(trip.StartedAt) {
+		return fmt.Errorf("%w: endedAt must be after startedAt", ErrInvalidTrip)
+	}
+
+	return nil
+}
+
+// Log records the trip, adds its fuel to the truck total and records its
+// odometer readings in the same transaction, which fails if they go below
+// the last reading of the truck. Without an explicit driver, whoever had the
+// truck when it started is used.
+func Log(ctx context.Context, repo interfaces.IRepository, trip *entities.Trip) error {
+	if err := Validate(trip); err != nil {
+		return err
+	}
+
+	trip.Distance = trip.EndOdometer.Sub(trip.StartOdometer)
+
+	return repo.WithTransaction(ctx, func(tx interfaces.IRepository) error {
+		if trip.DriverID == nil {
+			current, err := assignment.DriverAt(ctx, tx, trip.TruckID, trip.StartedAt)
+			if err != nil {
+				return err
+			}
+
+			if current.ID != 0 {
+				trip.DriverID = &current.DriverID
+			}
+		}
+
+		if err := tx.Create(ctx, trip); err != nil {
+			return err
+		}
+
+		if err := tx.Increment(ctx, &entities.Truck{}, trip.TruckID, "fuel_used", trip.FuelLiters); err != nil {
+			return err
+		}
+
+		return odometer.RecordTrip(ctx, tx, trip)
+	})
+}
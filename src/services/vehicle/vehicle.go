@@ -0,0 +1,130 @@
+package vehicle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mdelclaro/gobrax/src/apperr"
+	"github.com/mdelclaro/gobrax/src/repository/entities"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
+)
+
+var ErrInvalidSpecs = apperr.New(apperr.Validation, "invalid vehicle specs")
+
+// vinValues transliterates the characters a VIN can hold, I, O and Q are
+// left out as they read like 1 and 0.
+var vinValues = map[rune]int{
+	'0': 0, '1': 1, '2': 2, '3': 3, '4': 4, '5': 5, '6': 6, '7': 7, '8': 8, '9': 9,
+	'A': 1, 'B': 2, 'C': 3, 'D': 4, 'E': 5, 'F': 6, 'G': 7, 'H': 8,
+	'J': 1, 'K': 2, 'L': 3, 'M': 4, 'N': 5, 'P': 7, 'R': 9,
+	'S': 2, 'T': 3, 'U': 4, 'V': 5, 'W': 6, 'X': 7, 'Y': 8, 'Z': 9,
+}
+
+var vinWeights = [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// ValidVIN reports whether vin is 17 valid characters and its ninth one is
+// the ISO 3779 check digit of the rest, X standing for 10.
+func ValidVIN(vin string) bool {
+	if len(vin) != len(vinWeights) {
+		return false
+	}
+
+	sum := 0
+	for i, char := range vin {
+		value, ok := vinValues[char]
+		if !ok {
+			return false
+		}
+
+		sum += value * vinWeights[i]
+	}
+
+	check := byte('X')
+	if rest := sum % 11; rest < 10 {
+		check = byte('0' + rest)
+	}
+
+	return vin[8] == check
+}
+
+// ValidateSpecs checks the specs the validator can't, decimals aren't
+// compared by it.
+func ValidateSpecs(specs entities.VehicleSpecs) error {
+	if specs.GrossVehicleWeight.IsNegative() {
+		return fmt.Errorf("%w: grossVehicleWeight can't be negative", ErrInvalidSpecs)
+	}
+
+	if specs.TankCapacity.IsNegative() {
+		return fmt.Errorf("%w: tankCapacity can't be negative", ErrInvalidSpecs)
+	}
+
+	return nil
+}
+
+// ApplyModel fills the specs the truck leaves empty from its catalog model,
+// if it references one.
+func ApplyModel(ctx context.Context, repo interfaces.IRepository, truck *entities.Truck) error {
+	if truck.VehicleModelID == nil {
+		return nil
+	}
+
+	model := entities.VehicleModel{}
+	if err := repo.FindById(ctx, &model, *truck.VehicleModelID); err != nil {
+		return err
+	}
+
+	if truck.Make == "" {
+		truck.Make = model.Make
+	}
+
+	if truck.Model == "" {
+		truck.Model = model.Model
+	}
+
+	if truck.Year == 0 {
+		truck.Year = model.Year
+	}
+
+	if truck.AxleCount == 0 {
+		truck.AxleCount = model.AxleCount
+	}
+
+	if truck.GrossVehicleWeight.IsZero() {
+		truck.GrossVehicleWeight = model.GrossVehicleWeight
+	}
+
+	if truck.FuelType == "" {
+		truck.FuelType = model.FuelType
+	}
+
+	if truck.TankCapacity.IsZero() {
+		truck.TankCapacity = model.TankCapacity
+	}
+
+	if truck.RequiredLicenseCategory == "" {
+		truck.RequiredLicenseCategory = model.RequiredLicenseCategory
+	}
+
+	return nil
+}
+
+// ModelColumns returns the columns of the specs a truck takes from the
+// model, the ones in skip are left out.
+func ModelColumns(model entities.VehicleModel, skip map[string]any) map[string]any {
+	columns := map[string]any{
+		"make":                      model.Make,
+		"model":                     model.Model,
+		"year":                      model.Year,
+		"axle_count":                model.AxleCount,
+		"gross_vehicle_weight":      model.GrossVehicleWeight,
+		"fuel_type":                 model.FuelType,
+		"tank_capacity":             model.TankCapacity,
+		"required_license_category": model.RequiredLicenseCategory,
+	}
+
+	for column := range skip {
+		delete(columns, column)
+	}
+
+	return columns
+}
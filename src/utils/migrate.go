@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/mdelclaro/gobrax/src/config"
+	database "github.com/mdelclaro/gobrax/src/db"
+	"github.com/mdelclaro/gobrax/src/db/migrations"
+)
+
+const migrateUsage = `usage: migrate <command>
+
+commands:
+  up                 apply every pending migration
+  down [steps]       roll back the last applied migration(s), 1 by default
+  status             list migrations and when they were applied
+  create [-dir d] <name>
+                     add an empty up/down pair for a new migration
+`
+
+// RunMigrate runs the migrate subcommand of the binary with its args.
+func RunMigrate(cfg config.Database, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	all, err := migrations.All()
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "create" {
+		return createMigration(args[1:], all, out)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	migrator := migrations.NewMigrator(db, all)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %04d_%s\n", migration.Version, migration.Name)
+		}
+
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps provided: %s", args[1])
+			}
+		}
+
+		rolledBack, err := migrator.Down(steps)
+		for _, migration := range rolledBack {
+			fmt.Fprintf(out, "rolled back %04d_%s\n", migration.Version, migration.Name)
+		}
+
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+
+			fmt.Fprintf(out, "%04d_%-40s %s\n", status.Version, status.Name, appliedAt)
+		}
+
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+func createMigration(args []string, all []migrations.Migration, out io.Writer) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	flags.SetOutput(out)
+	dir := flags.String("dir", "src/db/migrations", "directory of the migration files")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	up, down, err := migrations.Create(*dir, flags.Arg(0), all)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "created %s\ncreated %s\n", up, down)
+	return nil
+}
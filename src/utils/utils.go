@@ -1,14 +1,30 @@
 package utils
 
 import (
+	"log"
+
 	"github.com/gofiber/fiber/v3"
+	"github.com/mdelclaro/gobrax/src/api/helpers"
+	"github.com/mdelclaro/gobrax/src/api/middleware"
 	"github.com/mdelclaro/gobrax/src/api/routes"
+	"github.com/mdelclaro/gobrax/src/config"
+	"github.com/mdelclaro/gobrax/src/repository/interfaces"
 )
 
-func SetupApp() *fiber.App {
-	app := fiber.New()
+func SetupApp(cfg config.Config, repo interfaces.IRepository) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: helpers.ErrorHandler,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	})
+
+	authCfg, err := middleware.LoadAuthConfig(cfg.Auth)
+	if err != nil {
+		log.Fatal("Failed to load auth config. \n", err)
+	}
 
-	routes.SetUpRoutes(app)
+	app.Use(middleware.QueryTimeout(cfg.Database.QueryTimeout))
+	routes.SetUpRoutes(app, authCfg, repo)
 
 	return app
 }